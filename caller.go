@@ -0,0 +1,102 @@
+package robologger
+
+import (
+  "fmt"
+  "path/filepath"
+  "runtime"
+)
+
+// Caller describes the location in the source code where a message was logged.
+type Caller struct {
+  File string
+  Line int
+  Function string
+}
+
+// String returns the location of the caller as "dir/file.go:line", which is
+// the file name and the directory it is in.
+func (c Caller) String() string {
+  dir, file := filepath.Split(c.File)
+  return fmt.Sprintf("%s:%d", filepath.Join(filepath.Base(dir), file), c.Line)
+}
+
+// captureCallers reports whether the logger records the caller of each log
+// message.
+func (l *Logger) captureCallers() bool {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  return l.callers
+}
+
+// SetCaller turns the caller capture mode of the logger on or off. When it is
+// on, the file, line and function of the call site are recorded for every log
+// message.
+func (l *Logger) SetCaller(on bool) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.callers = on
+}
+
+// SetCaller turns the caller capture mode of the default logger on or off.
+func SetCaller(on bool) {
+  std.SetCaller(on)
+}
+
+// caller returns the caller skip frames above the function that called caller,
+// so that caller(0) returns the function calling caller. Functions which have
+// been marked as helpers are skipped.
+func (l *Logger) caller(skip int) *Caller {
+  pcs := make([]uintptr, 32)
+
+  // runtime.Callers, caller and the function calling caller.
+  n := runtime.Callers(skip + 2, pcs)
+
+  l.mu.Lock()
+  defer l.mu.Unlock()
+
+  frames := runtime.CallersFrames(pcs[:n])
+
+  for {
+    frame, more := frames.Next()
+
+    if _, ok := l.helpers[frame.Function]; !ok || !more {
+      return &Caller{frame.File, frame.Line, frame.Function}
+    }
+  }
+}
+
+// Helper marks the calling function as a helper function. When the caller of a
+// message is recorded, helper functions are skipped, so that the location of
+// the call to the helper is recorded instead. It is meant to be used by
+// functions which wrap the logging methods.
+func (l *Logger) Helper() {
+  l.helper(1)
+}
+
+// Helper marks the calling function as a helper function of the default
+// logger.
+func Helper() {
+  std.helper(1)
+}
+
+// helper marks the function skip frames above the function that called helper
+// as a helper function.
+func (l *Logger) helper(skip int) {
+  pc, _, _, ok := runtime.Caller(skip + 1)
+  if !ok {
+    return
+  }
+
+  fn := runtime.FuncForPC(pc)
+  if fn == nil {
+    return
+  }
+
+  l.mu.Lock()
+  defer l.mu.Unlock()
+
+  if l.helpers == nil {
+    l.helpers = make(map[string]struct{})
+  }
+  l.helpers[fn.Name()] = struct{}{}
+}
@@ -1,6 +1,65 @@
 package robologger
 
-import "fmt"
+import (
+  "fmt"
+  "os"
+  "sync/atomic"
+)
+
+// ColorMode decides whether colors are printed.
+type ColorMode int32
+
+// Color modes.
+const (
+  // CM_AUTO prints colors to terminals only.
+  CM_AUTO ColorMode = iota
+  // CM_ALWAYS prints colors, even to output which is not a terminal.
+  CM_ALWAYS
+  // CM_NEVER prints no colors. Color returns an empty string, so messages
+  // are formatted without colors.
+  CM_NEVER
+)
+
+// colorMode holds the current ColorMode. It is detected from the environment
+// when the program starts.
+var colorMode = int32(envColorMode())
+
+// envColorMode returns the ColorMode requested by the environment, following
+// the NO_COLOR, FORCE_COLOR and CLICOLOR conventions. A dumb terminal gets no
+// colors.
+func envColorMode() ColorMode {
+  if os.Getenv("NO_COLOR") != "" {
+    return CM_NEVER
+  }
+
+  if v := os.Getenv("FORCE_COLOR"); v != "" {
+    if v == "0" || v == "false" {
+      return CM_NEVER
+    }
+    return CM_ALWAYS
+  }
+
+  if v := os.Getenv("CLICOLOR_FORCE"); v != "" && v != "0" {
+    return CM_ALWAYS
+  }
+
+  if os.Getenv("CLICOLOR") == "0" || os.Getenv("TERM") == "dumb" {
+    return CM_NEVER
+  }
+
+  return CM_AUTO
+}
+
+// SetColorMode sets the ColorMode, overriding the mode detected from the
+// environment.
+func SetColorMode(mode ColorMode) {
+  atomic.StoreInt32(&colorMode, int32(mode))
+}
+
+// GetColorMode returns the current ColorMode.
+func GetColorMode() ColorMode {
+  return ColorMode(atomic.LoadInt32(&colorMode))
+}
 
 type ColorType int
 
@@ -45,6 +104,8 @@ const (
   C_CYAN_BG
   C_WHITE_BG
   C_DEFAULT_BG
+
+  C_DIM
 )
 
 // String returns the ColorType as a string.
@@ -53,125 +114,133 @@ func (flags ColorType) String() string {
   return s
 }
 
-// Color returns the ANSI string corresponding to a ColorType.
+// Color returns the ANSI string corresponding to a ColorType. If the ColorMode
+// is CM_NEVER, it returns an empty string.
 func Color(flags ColorType) string {
   var s string
 
+  if GetColorMode() == CM_NEVER {
+    return s
+  }
+
   for flags > 0 {
     switch {
     case flags&C_RESET != 0:
-      s = s + fmt.Sprintf("%c[0m", term.ESC)
+      s = s + fmt.Sprintf("%c[0m", ESC)
       flags ^= C_RESET
     case flags&C_BOLD != 0:
-      s = s + fmt.Sprintf("%c[1m", term.ESC)
+      s = s + fmt.Sprintf("%c[1m", ESC)
       flags ^= C_BOLD
+    case flags&C_DIM != 0:
+      s = s + fmt.Sprintf("%c[2m", ESC)
+      flags ^= C_DIM
     case flags&C_ITALIC != 0:
-      s = s + fmt.Sprintf("%c[3m", term.ESC)
+      s = s + fmt.Sprintf("%c[3m", ESC)
       flags ^= C_ITALIC
     case flags&C_UNDERLINE != 0:
-      s = s + fmt.Sprintf("%c[4m", term.ESC)
+      s = s + fmt.Sprintf("%c[4m", ESC)
       flags ^= C_UNDERLINE
     case flags&C_INVERSE != 0:
-      s = s + fmt.Sprintf("%c[7m", term.ESC)
+      s = s + fmt.Sprintf("%c[7m", ESC)
       flags ^= C_INVERSE
     case flags&C_STRIKETHROUGH != 0:
-      s = s + fmt.Sprintf("%c[9m", term.ESC)
+      s = s + fmt.Sprintf("%c[9m", ESC)
       flags ^= C_STRIKETHROUGH
     case flags&C_BOLD_OFF != 0:
-      s = s + fmt.Sprintf("%c[22m", term.ESC)
+      s = s + fmt.Sprintf("%c[22m", ESC)
       flags ^= C_BOLD_OFF
     case flags&C_ITALIC_OFF != 0:
-      s = s + fmt.Sprintf("%c[23m", term.ESC)
+      s = s + fmt.Sprintf("%c[23m", ESC)
       flags ^= C_ITALIC_OFF
     case flags&C_UNDERLINE_OFF != 0:
-      s = s + fmt.Sprintf("%c[24m", term.ESC)
+      s = s + fmt.Sprintf("%c[24m", ESC)
       flags ^= C_UNDERLINE_OFF
     case flags&C_INVERSE_OFF != 0:
-      s = s + fmt.Sprintf("%c[27m", term.ESC)
+      s = s + fmt.Sprintf("%c[27m", ESC)
       flags ^= C_INVERSE_OFF
     case flags&C_STRIKETHROUGH_OFF != 0:
-      s = s + fmt.Sprintf("%c[29m", term.ESC)
+      s = s + fmt.Sprintf("%c[29m", ESC)
       flags ^= C_STRIKETHROUGH_OFF
     // Foreground colors.
     case flags&C_BLACK_FG != 0:
-      s = s + fmt.Sprintf("%c[30m", term.ESC)
+      s = s + fmt.Sprintf("%c[30m", ESC)
       flags ^= C_BLACK_FG
     case flags&C_RED_FG != 0:
-      s = s + fmt.Sprintf("%c[31m", term.ESC)
+      s = s + fmt.Sprintf("%c[31m", ESC)
       flags ^= C_RED_FG
     case flags&C_GREEN_FG != 0:
-      s = s + fmt.Sprintf("%c[32m", term.ESC)
+      s = s + fmt.Sprintf("%c[32m", ESC)
       flags ^= C_GREEN_FG
     case flags&C_YELLOW_FG != 0:
-      s = s + fmt.Sprintf("%c[33m", term.ESC)
+      s = s + fmt.Sprintf("%c[33m", ESC)
       flags ^= C_YELLOW_FG
     case flags&C_BLUE_FG != 0:
-      s = s + fmt.Sprintf("%c[34m", term.ESC)
+      s = s + fmt.Sprintf("%c[34m", ESC)
       flags ^= C_BLUE_FG
     case flags&C_MAGENTA_FG != 0:
-      s = s + fmt.Sprintf("%c[35m", term.ESC)
+      s = s + fmt.Sprintf("%c[35m", ESC)
       flags ^= C_MAGENTA_FG
     case flags&C_CYAN_FG != 0:
-      s = s + fmt.Sprintf("%c[36m", term.ESC)
+      s = s + fmt.Sprintf("%c[36m", ESC)
       flags ^= C_CYAN_FG
     case flags&C_GRAY_FG != 0:
-      s = s + fmt.Sprintf("%c[37m", term.ESC)
+      s = s + fmt.Sprintf("%c[37m", ESC)
       flags ^= C_GRAY_FG
     case flags&C_DEFAULT_FG != 0:
-      s = s + fmt.Sprintf("%c[39m", term.ESC)
+      s = s + fmt.Sprintf("%c[39m", ESC)
       flags ^= C_DEFAULT_FG
     // Light foreground colors.
   case flags&C_DARK_GRAY_FG != 0:
-      s = s + fmt.Sprintf("%c[90m", term.ESC)
+      s = s + fmt.Sprintf("%c[90m", ESC)
       flags ^= C_DARK_GRAY_FG
     case flags&C_LIGHT_RED_FG != 0:
-        s = s + fmt.Sprintf("%c[91m", term.ESC)
+        s = s + fmt.Sprintf("%c[91m", ESC)
         flags ^= C_LIGHT_RED_FG
     case flags&C_LIGHT_GREEN_FG != 0:
-      s = s + fmt.Sprintf("%c[92m", term.ESC)
+      s = s + fmt.Sprintf("%c[92m", ESC)
       flags ^= C_LIGHT_GREEN_FG
     case flags&C_LIGHT_YELLOW_FG != 0:
-      s = s + fmt.Sprintf("%c[93m", term.ESC)
+      s = s + fmt.Sprintf("%c[93m", ESC)
       flags ^= C_LIGHT_YELLOW_FG
     case flags&C_LIGHT_BLUE_FG != 0:
-      s = s + fmt.Sprintf("%c[94m", term.ESC)
+      s = s + fmt.Sprintf("%c[94m", ESC)
       flags ^= C_LIGHT_BLUE_FG
     case flags&C_LIGHT_MAGENTA_FG != 0:
-      s = s + fmt.Sprintf("%c[95m", term.ESC)
+      s = s + fmt.Sprintf("%c[95m", ESC)
       flags ^= C_LIGHT_MAGENTA_FG
     case flags&C_LIGHT_CYAN_FG != 0:
-      s = s + fmt.Sprintf("%c[96m", term.ESC)
+      s = s + fmt.Sprintf("%c[96m", ESC)
       flags ^= C_LIGHT_CYAN_FG
     case flags&C_WHITE_FG != 0:
-      s = s + fmt.Sprintf("%c[97m", term.ESC)
+      s = s + fmt.Sprintf("%c[97m", ESC)
       flags ^= C_WHITE_FG
     // Background colors.
     case flags&C_BLACK_BG != 0:
-      s = s + fmt.Sprintf("%c[40m", term.ESC)
+      s = s + fmt.Sprintf("%c[40m", ESC)
       flags ^= C_BLACK_BG
     case flags&C_RED_BG != 0:
-      s = s + fmt.Sprintf("%c[41m", term.ESC)
+      s = s + fmt.Sprintf("%c[41m", ESC)
       flags ^= C_RED_BG
     case flags&C_GREEN_BG != 0:
-      s = s + fmt.Sprintf("%c[42m", term.ESC)
+      s = s + fmt.Sprintf("%c[42m", ESC)
       flags ^= C_GREEN_BG
     case flags&C_YELLOW_BG != 0:
-      s = s + fmt.Sprintf("%c[43m", term.ESC)
+      s = s + fmt.Sprintf("%c[43m", ESC)
       flags ^= C_YELLOW_BG
     case flags&C_BLUE_BG != 0:
-      s = s + fmt.Sprintf("%c[44m", term.ESC)
+      s = s + fmt.Sprintf("%c[44m", ESC)
       flags ^= C_BLUE_BG
     case flags&C_MAGENTA_BG != 0:
-      s = s + fmt.Sprintf("%c[45m", term.ESC)
+      s = s + fmt.Sprintf("%c[45m", ESC)
       flags ^= C_MAGENTA_BG
     case flags&C_CYAN_BG != 0:
-      s = s + fmt.Sprintf("%c[46m", term.ESC)
+      s = s + fmt.Sprintf("%c[46m", ESC)
       flags ^= C_CYAN_BG
     case flags&C_WHITE_BG != 0:
-      s = s + fmt.Sprintf("%c[47m", term.ESC)
+      s = s + fmt.Sprintf("%c[47m", ESC)
       flags ^= C_WHITE_BG
     case flags&C_DEFAULT_BG != 0:
-      s = s + fmt.Sprintf("%c[49m", term.ESC)
+      s = s + fmt.Sprintf("%c[49m", ESC)
       flags ^= C_DEFAULT_BG
     }
   }
@@ -0,0 +1,44 @@
+package robologger
+
+import (
+  "testing"
+)
+
+func TestEnvColorMode(t *testing.T) {
+  tests := []struct {
+    noColor string
+    forceColor string
+    clicolor string
+    clicolorForce string
+    term string
+    mode ColorMode
+  }{
+    {"", "", "", "", "xterm", CM_AUTO},
+    {"1", "", "", "", "xterm", CM_NEVER},
+    {"1", "1", "", "", "xterm", CM_NEVER},
+    {"", "1", "", "", "xterm", CM_ALWAYS},
+    {"", "1", "", "", "dumb", CM_ALWAYS},
+    {"", "0", "", "", "xterm", CM_NEVER},
+    {"", "0", "", "1", "xterm", CM_NEVER},
+    {"", "false", "", "", "xterm", CM_NEVER},
+    {"", "", "0", "", "xterm", CM_NEVER},
+    {"", "", "0", "1", "xterm", CM_ALWAYS},
+    {"", "", "0", "0", "xterm", CM_NEVER},
+    {"", "", "1", "", "xterm", CM_AUTO},
+    {"", "", "", "", "dumb", CM_NEVER},
+    {"", "", "", "1", "dumb", CM_ALWAYS},
+  }
+
+  for _, test := range tests {
+    t.Setenv("NO_COLOR", test.noColor)
+    t.Setenv("FORCE_COLOR", test.forceColor)
+    t.Setenv("CLICOLOR", test.clicolor)
+    t.Setenv("CLICOLOR_FORCE", test.clicolorForce)
+    t.Setenv("TERM", test.term)
+
+    if mode := envColorMode(); mode != test.mode {
+      t.Errorf("NO_COLOR=%q FORCE_COLOR=%q CLICOLOR=%q CLICOLOR_FORCE=%q TERM=%q: got mode %d, want %d",
+        test.noColor, test.forceColor, test.clicolor, test.clicolorForce, test.term, mode, test.mode)
+    }
+  }
+}
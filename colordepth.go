@@ -0,0 +1,325 @@
+package robologger
+
+import (
+  "encoding/binary"
+  "io/ioutil"
+  "math"
+  "os"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "sync"
+  "sync/atomic"
+)
+
+// ColorDepth is the number of colors the terminal can print.
+type ColorDepth int32
+
+// Color depths.
+const (
+  CD_16 ColorDepth = iota
+  CD_256
+  CD_TRUECOLOR
+)
+
+// colorDepth holds the current ColorDepth. It is detected from the environment
+// when the program starts.
+var colorDepth = int32(envColorDepth())
+
+// envColorDepth returns the ColorDepth of the terminal, detected from the
+// COLORTERM and TERM environment variables and from the terminfo entry of the
+// terminal. It defaults to CD_16.
+func envColorDepth() ColorDepth {
+  switch strings.ToLower(os.Getenv("COLORTERM")) {
+  case "truecolor", "24bit":
+    return CD_TRUECOLOR
+  }
+
+  // Windows Terminal supports 24-bit colors, but does not set COLORTERM.
+  if os.Getenv("WT_SESSION") != "" {
+    return CD_TRUECOLOR
+  }
+
+  term := os.Getenv("TERM")
+
+  switch {
+  case term == "":
+    return CD_16
+  case strings.HasSuffix(term, "-direct") || strings.Contains(term, "truecolor"):
+    return CD_TRUECOLOR
+  case strings.Contains(term, "256color"):
+    return CD_256
+  }
+
+  switch n := terminfoColors(term); {
+  case n >= 1 << 24:
+    return CD_TRUECOLOR
+  case n >= 256:
+    return CD_256
+  }
+
+  return CD_16
+}
+
+// terminfoColors returns the number of colors of the terminal term, read from
+// the max_colors capability of its compiled terminfo entry. It returns zero if
+// the entry is not found.
+func terminfoColors(term string) int {
+  if strings.ContainsAny(term, "/\\") {
+    return 0
+  }
+
+  var dirs []string
+
+  if dir := os.Getenv("TERMINFO"); dir != "" {
+    dirs = append(dirs, dir)
+  }
+  if home, err := os.UserHomeDir(); err == nil {
+    dirs = append(dirs, filepath.Join(home, ".terminfo"))
+  }
+  for _, dir := range filepath.SplitList(os.Getenv("TERMINFO_DIRS")) {
+    if dir != "" {
+      dirs = append(dirs, dir)
+    }
+  }
+  dirs = append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo", "/usr/lib/terminfo")
+
+  for _, dir := range dirs {
+    // Entries are stored under their first letter, or its hex code on
+    // systems with case insensitive file names.
+    for _, sub := range []string{term[:1], strconv.FormatInt(int64(term[0]), 16)} {
+      b, err := ioutil.ReadFile(filepath.Join(dir, sub, term))
+      if err == nil {
+        return parseTerminfoColors(b)
+      }
+    }
+  }
+
+  return 0
+}
+
+// parseTerminfoColors returns the max_colors capability of the compiled
+// terminfo entry b, described in term(5).
+func parseTerminfoColors(b []byte) int {
+  // maxColors is the index of max_colors in the numbers section.
+  const maxColors = 13
+
+  if len(b) < 12 {
+    return 0
+  }
+
+  header := make([]int, 6)
+  for i := range header {
+    header[i] = int(int16(binary.LittleEndian.Uint16(b[i * 2:])))
+  }
+
+  // The extended format stores the numbers as 32-bit integers.
+  var size int
+
+  switch header[0] {
+  case 0432:
+    size = 2
+  case 01036:
+    size = 4
+  default:
+    return 0
+  }
+
+  names, bools, nums := header[1], header[2], header[3]
+  if names < 0 || bools < 0 || nums <= maxColors {
+    return 0
+  }
+
+  // The numbers section is aligned on an even byte.
+  off := 12 + names + bools
+  if off % 2 != 0 {
+    off = off + 1
+  }
+
+  off = off + maxColors * size
+  if off + size > len(b) {
+    return 0
+  }
+
+  if size == 2 {
+    return int(int16(binary.LittleEndian.Uint16(b[off:])))
+  }
+  return int(int32(binary.LittleEndian.Uint32(b[off:])))
+}
+
+// SetColorDepth sets the ColorDepth, overriding the depth detected from the
+// environment.
+func SetColorDepth(depth ColorDepth) {
+  atomic.StoreInt32(&colorDepth, int32(depth))
+}
+
+// GetColorDepth returns the current ColorDepth.
+func GetColorDepth() ColorDepth {
+  return ColorDepth(atomic.LoadInt32(&colorDepth))
+}
+
+// lab is a color in the CIELAB color space, where the euclidean distance
+// between two colors approximates the perceived difference between them.
+type lab struct {
+  l, a, b float64
+}
+
+// toLab converts the sRGB color r, g, b to CIELAB, using the D65 white point.
+func toLab(r, g, b uint8) lab {
+  linear := func(v uint8) float64 {
+    c := float64(v) / 255
+    if c <= 0.04045 {
+      return c / 12.92
+    }
+    return math.Pow((c + 0.055) / 1.055, 2.4)
+  }
+
+  lr, lg, lb := linear(r), linear(g), linear(b)
+
+  x := (0.4124 * lr + 0.3576 * lg + 0.1805 * lb) / 0.95047
+  y := (0.2126 * lr + 0.7152 * lg + 0.0722 * lb) / 1.00000
+  z := (0.0193 * lr + 0.1192 * lg + 0.9505 * lb) / 1.08883
+
+  f := func(t float64) float64 {
+    if t > 216.0 / 24389 {
+      return math.Cbrt(t)
+    }
+    return (24389.0 / 27 * t + 16) / 116
+  }
+
+  fx, fy, fz := f(x), f(y), f(z)
+
+  return lab{116 * fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// distance returns the squared perceptual distance between the colors c and d.
+func (c lab) distance(d lab) float64 {
+  dl, da, db := c.l - d.l, c.a - d.a, c.b - d.b
+  return dl * dl + da * da + db * db
+}
+
+// paletteLab holds the CIELAB values of the 256 color palette, computed once.
+var paletteLab struct {
+  once sync.Once
+  colors [256]lab
+}
+
+// nearestPalette returns the entry of the palette, from lo to hi inclusive,
+// which looks the most like the color r, g, b.
+func nearestPalette(r, g, b uint8, lo int, hi int) int {
+  paletteLab.once.Do(func() {
+    for i := range paletteLab.colors {
+      paletteLab.colors[i] = toLab(paletteRGB(i))
+    }
+  })
+
+  c := toLab(r, g, b)
+  best, min := lo, math.Inf(1)
+
+  for i := lo; i <= hi; i++ {
+    if d := c.distance(paletteLab.colors[i]); d < min {
+      best, min = i, d
+    }
+  }
+
+  return best
+}
+
+// downsample replaces the colors in the SGR sequences of s which the terminal
+// cannot print at the depth depth by the nearest colors of the palette. The 256
+// color cube and grayscale ramp are used for CD_256, since the first 16 colors
+// are often customized, and the 16 color palette for CD_16.
+func downsample(s string, depth ColorDepth) string {
+  if depth >= CD_TRUECOLOR || !strings.Contains(s, "\x1b[") {
+    return s
+  }
+
+  var b strings.Builder
+
+  for len(s) > 0 {
+    n := ansiLength(s)
+    if n == 0 {
+      i := strings.Index(s[1:], "\x1b[")
+      if i < 0 {
+        b.WriteString(s)
+        break
+      }
+      b.WriteString(s[:i + 1])
+      s = s[i + 1:]
+      continue
+    }
+
+    seq := s[:n]
+    s = s[n:]
+
+    if seq[n - 1] == 'm' {
+      seq = downsampleSGR(seq, depth)
+    }
+
+    b.WriteString(seq)
+  }
+
+  return b.String()
+}
+
+// downsampleSGR downsamples the colors of the SGR sequence seq to the depth.
+func downsampleSGR(seq string, depth ColorDepth) string {
+  params := strings.Split(seq[2:len(seq) - 1], ";")
+
+  var out []string
+
+  for i := 0; i < len(params); i++ {
+    p := params[i]
+
+    if (p != "38" && p != "48") || i + 1 >= len(params) {
+      out = append(out, p)
+      continue
+    }
+
+    base := 30
+    if p == "48" {
+      base = 40
+    }
+
+    var c ColorValue
+
+    switch {
+    case params[i + 1] == "5" && i + 2 < len(params):
+      n, _ := strconv.Atoi(params[i + 2])
+      c = ANSI256(n)
+      i = i + 2
+    case params[i + 1] == "2" && i + 4 < len(params):
+      r, _ := strconv.Atoi(params[i + 2])
+      g, _ := strconv.Atoi(params[i + 3])
+      bl, _ := strconv.Atoi(params[i + 4])
+      c = RGB(uint8(r), uint8(g), uint8(bl))
+      i = i + 4
+    default:
+      out = append(out, p)
+      continue
+    }
+
+    out = append(out, downsampleColor(c, depth).params(base))
+  }
+
+  return sgr(out)
+}
+
+// downsampleColor returns the color of the palette of the depth which looks the
+// most like the color c.
+func downsampleColor(c ColorValue, depth ColorDepth) ColorValue {
+  space := c & colorSpace
+
+  switch {
+  case depth >= CD_TRUECOLOR, space == color16:
+    return c
+  case space == color256 && (depth == CD_256 || c.index() < 16):
+    return c
+  case depth == CD_256:
+    r, g, b := c.RGB()
+    return ANSI256(nearestPalette(r, g, b, 16, 255))
+  }
+
+  r, g, b := c.RGB()
+  return ANSI16(nearestPalette(r, g, b, 0, 15))
+}
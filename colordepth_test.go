@@ -0,0 +1,101 @@
+package robologger
+
+import (
+  "encoding/binary"
+  "os"
+  "path/filepath"
+  "testing"
+)
+
+// terminfoEntry returns a compiled terminfo entry with the max_colors
+// capability colors, in the legacy or the extended number format.
+func terminfoEntry(colors int, extended bool) []byte {
+  names := []byte("test|test terminal\x00")
+  size, magic := 2, 0432
+  if extended {
+    size, magic = 4, 01036
+  }
+
+  b := make([]byte, 12)
+  for i, v := range []int{magic, len(names), 1, 15, 0, 0} {
+    binary.LittleEndian.PutUint16(b[i * 2:], uint16(v))
+  }
+
+  b = append(b, names...)
+  b = append(b, 1)
+  if len(b) % 2 != 0 {
+    b = append(b, 0)
+  }
+
+  nums := make([]byte, 15 * size)
+  for i := 0; i < 15; i++ {
+    v := -1
+    if i == 13 {
+      v = colors
+    }
+    if extended {
+      binary.LittleEndian.PutUint32(nums[i * size:], uint32(v))
+    } else {
+      binary.LittleEndian.PutUint16(nums[i * size:], uint16(v))
+    }
+  }
+
+  return append(b, nums...)
+}
+
+func TestEnvColorDepth(t *testing.T) {
+  dir := t.TempDir()
+  os.MkdirAll(filepath.Join(dir, "r"), 0755)
+  os.WriteFile(filepath.Join(dir, "r", "robot-256"), terminfoEntry(256, false), 0644)
+  os.WriteFile(filepath.Join(dir, "r", "robot-rgb"), terminfoEntry(1 << 24, true), 0644)
+
+  t.Setenv("TERMINFO", dir)
+  t.Setenv("WT_SESSION", "")
+
+  tests := []struct {
+    colorterm string
+    term string
+    depth ColorDepth
+  }{
+    {"truecolor", "xterm", CD_TRUECOLOR},
+    {"24bit", "", CD_TRUECOLOR},
+    {"", "xterm-direct", CD_TRUECOLOR},
+    {"", "screen-256color", CD_256},
+    {"", "robot-256", CD_256},
+    {"", "robot-rgb", CD_TRUECOLOR},
+    {"", "robot-unknown", CD_16},
+    {"", "", CD_16},
+  }
+
+  for _, test := range tests {
+    t.Setenv("COLORTERM", test.colorterm)
+    t.Setenv("TERM", test.term)
+
+    if depth := envColorDepth(); depth != test.depth {
+      t.Errorf("COLORTERM=%q TERM=%q: got depth %d, want %d", test.colorterm, test.term, depth, test.depth)
+    }
+  }
+}
+
+func TestDownsample(t *testing.T) {
+  tests := []struct {
+    s string
+    depth ColorDepth
+    want string
+  }{
+    {"\x1b[38;2;255;136;0mhot\x1b[0m", CD_TRUECOLOR, "\x1b[38;2;255;136;0mhot\x1b[0m"},
+    {"\x1b[38;2;255;136;0mhot\x1b[0m", CD_256, "\x1b[38;5;208mhot\x1b[0m"},
+    {"\x1b[38;2;255;136;0mhot\x1b[0m", CD_16, "\x1b[31mhot\x1b[0m"},
+    {"\x1b[1;48;2;10;10;200;4mcold", CD_16, "\x1b[1;44;4mcold"},
+    {"\x1b[38;5;196mred", CD_256, "\x1b[38;5;196mred"},
+    {"\x1b[38;5;196mred", CD_16, "\x1b[91mred"},
+    {"\x1b[48;5;236mgray", CD_16, "\x1b[40mgray"},
+    {"\x1b[31mred\x1b[K", CD_16, "\x1b[31mred\x1b[K"},
+  }
+
+  for _, test := range tests {
+    if got := downsample(test.s, test.depth); got != test.want {
+      t.Errorf("downsample(%q, %d) = %q, want %q", test.s, test.depth, got, test.want)
+    }
+  }
+}
@@ -0,0 +1,306 @@
+package robologger
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "regexp"
+  "strconv"
+  "strings"
+  "time"
+  "unicode"
+)
+
+// Encoder is the interface that implements the Encode method.
+//
+// Encode returns the encoded form of the message msg, without a trailing
+// newline. If update is true, msg has already been written once and is being
+// encoded because it changed, e.g. a Status or Progress update.
+type Encoder interface {
+  Encode(msg Message, update bool) ([]byte, error)
+}
+
+// Message kinds, used by the encoders to describe the type of a message.
+const (
+  K_LOG      = "log"
+  K_STATUS   = "status"
+  K_PROGRESS = "progress"
+  K_PROMPT   = "prompt"
+)
+
+// messageKind returns the kind of the message msg.
+func messageKind(msg Message) string {
+  switch msg.(type) {
+  case *StatusMessage:
+    return K_STATUS
+  case *ProgressMessage:
+    return K_PROGRESS
+  case *PromptMessage:
+    return K_PROMPT
+  default:
+    return K_LOG
+  }
+}
+
+// messageLevel returns the level of the message msg. Status and Progress
+// messages are informational, and prompts are always printed.
+func messageLevel(msg Message) LogFlag {
+  switch m := msg.(type) {
+  case *LogMessage:
+    return m.flags
+  case *StatusMessage, *ProgressMessage:
+    return L_INFO
+  default:
+    return L_PRINT
+  }
+}
+
+// TextEncoder encodes messages using the terminal format produced by the Format
+// method of the message, e.g. "[INFO]  Hello.". If Color is false, the ANSI
+// color codes are removed from the text.
+type TextEncoder struct {
+  Color bool
+}
+
+// NewTextEncoder returns a new TextEncoder.
+func NewTextEncoder(color bool) *TextEncoder {
+  return &TextEncoder{Color: color}
+}
+
+// Encode is the implementation of the Encoder interface.
+func (e TextEncoder) Encode(msg Message, update bool) ([]byte, error) {
+  s := msg.Format()
+  if !e.Color {
+    s = stripANSI(s)
+  }
+  return []byte(s), nil
+}
+
+// ansiRegexp matches ANSI escape sequences.
+var ansiRegexp = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+// stripANSI removes ANSI escape sequences from the string s.
+func stripANSI(s string) string {
+  return ansiRegexp.ReplaceAllString(s, "")
+}
+
+// messageTime returns the time of the message msg. If update is true, the time
+// the message was last updated is returned.
+func messageTime(msg Message, update bool) time.Time {
+  if update {
+    return msg.Updated()
+  }
+  return msg.Time()
+}
+
+// JSONEncoder encodes messages as JSON objects, one object per message. Each
+// object holds the time, level, kind and text of the message, and the
+// structured fields of the message as a nested object.
+//
+//     {"time":"...","level":"info","kind":"log","msg":"Hello.","fields":{"id":1}}
+//
+// Status and Progress messages also hold an "event", which is "start" when the
+// message is first written and "update" when it is changed.
+type JSONEncoder struct{}
+
+// NewJSONEncoder returns a new JSONEncoder.
+func NewJSONEncoder() *JSONEncoder {
+  return new(JSONEncoder)
+}
+
+// Encode is the implementation of the Encoder interface.
+func (e JSONEncoder) Encode(msg Message, update bool) ([]byte, error) {
+  var buf bytes.Buffer
+
+  kind := messageKind(msg)
+
+  buf.WriteByte('{')
+
+  // Messages without a time, e.g. slog records with a zero time, are written
+  // without one.
+  if t := messageTime(msg, update); !t.IsZero() {
+    buf.WriteString(`"time":`)
+    buf.Write(marshalJSON(t.Format(time.RFC3339Nano)))
+    buf.WriteByte(',')
+  }
+
+  buf.WriteString(`"level":`)
+  buf.Write(marshalJSON(messageLevel(msg).String()))
+  buf.WriteString(`,"kind":`)
+  buf.Write(marshalJSON(kind))
+
+  switch kind {
+  case K_STATUS, K_PROGRESS:
+    event := "start"
+    if update {
+      event = "update"
+    }
+    buf.WriteString(`,"event":`)
+    buf.Write(marshalJSON(event))
+  }
+
+  if pm, ok := msg.(*ProgressMessage); ok {
+    buf.WriteString(fmt.Sprintf(`,"progress":%d`, pm.progress))
+  }
+
+  buf.WriteString(`,"msg":`)
+  buf.Write(marshalJSON(msg.String()))
+
+  if lm, ok := msg.(*LogMessage); ok && lm.caller != nil {
+    buf.WriteString(`,"caller":`)
+    buf.Write(marshalJSON(lm.caller.String()))
+    buf.WriteString(`,"func":`)
+    buf.Write(marshalJSON(lm.caller.Function))
+  }
+
+  if fields := msg.Fields(); len(fields) > 0 {
+    buf.WriteString(`,"fields":{`)
+    for i, field := range fields {
+      if i > 0 {
+        buf.WriteByte(',')
+      }
+      buf.Write(marshalJSON(field.Key))
+      buf.WriteByte(':')
+      buf.Write(marshalJSON(field.Value))
+    }
+    buf.WriteByte('}')
+  }
+
+  buf.WriteByte('}')
+
+  return buf.Bytes(), nil
+}
+
+// marshalJSON returns the JSON encoding of v. Errors are encoded using their
+// Error method, and values which cannot be encoded are encoded as strings.
+func marshalJSON(v interface{}) []byte {
+  if err, ok := v.(error); ok {
+    v = err.Error()
+  }
+
+  var buf bytes.Buffer
+
+  enc := json.NewEncoder(&buf)
+  enc.SetEscapeHTML(false)
+
+  if err := enc.Encode(v); err != nil {
+    return marshalJSON(fmt.Sprint(v))
+  }
+
+  return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+// LogfmtEncoder encodes messages as logfmt, a line of space separated key=value
+// pairs. Each line holds the time, level and text of the message, followed by
+// the structured fields of the message.
+//
+//     ts=... level=info msg="Hello, robot." id=1
+//
+// Status, Progress and Prompt messages also hold their kind, and Status and
+// Progress messages hold an event, which is "start" or "update".
+type LogfmtEncoder struct{}
+
+// NewLogfmtEncoder returns a new LogfmtEncoder.
+func NewLogfmtEncoder() *LogfmtEncoder {
+  return new(LogfmtEncoder)
+}
+
+// Encode is the implementation of the Encoder interface.
+func (e LogfmtEncoder) Encode(msg Message, update bool) ([]byte, error) {
+  var buf bytes.Buffer
+
+  kind := messageKind(msg)
+
+  if t := messageTime(msg, update); !t.IsZero() {
+    writeLogfmt(&buf, "ts", t)
+  }
+  writeLogfmt(&buf, "level", messageLevel(msg).String())
+
+  if kind != K_LOG {
+    writeLogfmt(&buf, "kind", kind)
+  }
+
+  switch kind {
+  case K_STATUS, K_PROGRESS:
+    event := "start"
+    if update {
+      event = "update"
+    }
+    writeLogfmt(&buf, "event", event)
+  }
+
+  if pm, ok := msg.(*ProgressMessage); ok {
+    writeLogfmt(&buf, "progress", pm.progress)
+  }
+
+  writeLogfmt(&buf, "msg", msg.String())
+
+  if lm, ok := msg.(*LogMessage); ok && lm.caller != nil {
+    writeLogfmt(&buf, "caller", lm.caller.String())
+    writeLogfmt(&buf, "func", lm.caller.Function)
+  }
+
+  for _, field := range msg.Fields() {
+    writeLogfmt(&buf, field.Key, field.Value)
+  }
+
+  return buf.Bytes(), nil
+}
+
+// writeLogfmt writes a logfmt key=value pair to buf, preceded by a space if buf
+// is not empty.
+func writeLogfmt(buf *bytes.Buffer, key string, value interface{}) {
+  if buf.Len() > 0 {
+    buf.WriteByte(' ')
+  }
+  buf.WriteString(logfmtKey(key))
+  buf.WriteByte('=')
+  buf.WriteString(logfmtValue(value))
+}
+
+// logfmtKey returns the key with any characters that are not allowed in a
+// logfmt key replaced by an underscore.
+func logfmtKey(key string) string {
+  if key == "" {
+    return "_"
+  }
+
+  return strings.Map(func(r rune) rune {
+    if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+      return '_'
+    }
+    return r
+  }, key)
+}
+
+// logfmtValue returns the value formatted for logfmt. Values which are empty or
+// contain spaces, quotes, equals signs or control characters are quoted and
+// escaped.
+func logfmtValue(value interface{}) string {
+  var s string
+
+  switch v := value.(type) {
+  case nil:
+    return "null"
+  case string:
+    s = v
+  case error:
+    s = v.Error()
+  case time.Time:
+    s = v.Format(time.RFC3339Nano)
+  default:
+    s = fmt.Sprint(v)
+  }
+
+  if s == "" {
+    return `""`
+  }
+
+  for _, r := range s {
+    if r <= ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+      return strconv.Quote(s)
+    }
+  }
+
+  return s
+}
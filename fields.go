@@ -0,0 +1,144 @@
+package robologger
+
+import (
+  "fmt"
+  "sort"
+  "strings"
+)
+
+// Field is a structured key/value pair attached to a message.
+type Field struct {
+  Key string
+  Value interface{}
+}
+
+// Fields is an ordered list of structured fields.
+type Fields []Field
+
+// badKey is the key used for a value passed to With without a string key.
+const badKey = "!BADKEY"
+
+// newFields converts a list of alternating keys and values into Fields. A key
+// which is not a string is converted using fmt.Sprint, and a trailing key
+// without a value is given a nil value.
+func newFields(kv ...interface{}) Fields {
+  var fields Fields
+
+  for i := 0; i < len(kv); i += 2 {
+    var key string
+
+    switch k := kv[i].(type) {
+    case string:
+      key = k
+    case nil:
+      key = badKey
+    default:
+      key = fmt.Sprint(k)
+    }
+
+    var value interface{}
+    if i + 1 < len(kv) {
+      value = kv[i + 1]
+    }
+
+    fields = append(fields, Field{key, value})
+  }
+
+  return fields
+}
+
+// Get returns the value of the last field with the key key.
+func (f Fields) Get(key string) (interface{}, bool) {
+  for i := len(f) - 1; i >= 0; i-- {
+    if f[i].Key == key {
+      return f[i].Value, true
+    }
+  }
+  return nil, false
+}
+
+// Map returns the fields as a map. Later fields override earlier fields with
+// the same key.
+func (f Fields) Map() map[string]interface{} {
+  m := make(map[string]interface{}, len(f))
+  for _, field := range f {
+    m[field.Key] = field.Value
+  }
+  return m
+}
+
+// String returns the fields as space separated key=value pairs.
+func (f Fields) String() string {
+  var s []string
+  for _, field := range f {
+    s = append(s, field.Key + "=" + formatFieldValue(field.Value))
+  }
+  return strings.Join(s, " ")
+}
+
+// Format returns the fields as dimmed, colored key=value pairs for printing to
+// the terminal. Each pair is preceded by a space.
+func (f Fields) Format() (fmsg string) {
+  for _, field := range f {
+    fmsg = fmsg + " " + Color(C_DIM | C_CYAN_FG) + field.Key + Color(C_RESET)
+    fmsg = fmsg + Color(C_DIM) + "=" + formatFieldValue(field.Value)
+    fmsg = fmsg + Color(C_RESET)
+  }
+  return
+}
+
+// formatFieldValue returns the value of a field as a string. Strings which are
+// empty or contain spaces, quotes or an equals sign are quoted.
+func formatFieldValue(v interface{}) string {
+  s := removeNewlines(fmt.Sprint(v))
+  if s == "" || strings.ContainsAny(s, " \t\"=") {
+    return fmt.Sprintf("%q", s)
+  }
+  return s
+}
+
+// With returns a logger derived from l which adds the alternating keys and
+// values kv as structured fields to every message. The derived logger shares
+// its history, printer and settings with l.
+func (l *Logger) With(kv ...interface{}) *Logger {
+  return l.withFields(newFields(kv...))
+}
+
+// WithFields returns a logger derived from l which adds the fields in m to
+// every message. The fields are sorted by key.
+func (l *Logger) WithFields(m map[string]interface{}) *Logger {
+  keys := make([]string, 0, len(m))
+  for k := range m {
+    keys = append(keys, k)
+  }
+  sort.Strings(keys)
+
+  fields := make(Fields, 0, len(m))
+  for _, k := range keys {
+    fields = append(fields, Field{k, m[k]})
+  }
+
+  return l.withFields(fields)
+}
+
+// withFields returns a logger derived from l with the fields appended to the
+// fields of l.
+func (l *Logger) withFields(fields Fields) *Logger {
+  nl := *l
+  nl.fields = make(Fields, 0, len(l.fields) + len(fields))
+  nl.fields = append(nl.fields, l.fields...)
+  nl.fields = append(nl.fields, fields...)
+  return &nl
+}
+
+// With returns a logger derived from the default logger which adds the
+// alternating keys and values kv as structured fields to every message.
+func With(kv ...interface{}) *Logger {
+  return std.With(kv...)
+}
+
+// WithFields returns a logger derived from the default logger which adds the
+// fields in m to every message.
+func WithFields(m map[string]interface{}) *Logger {
+  return std.WithFields(m)
+}
@@ -0,0 +1,439 @@
+package robologger
+
+import (
+  "bufio"
+  "compress/gzip"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "sort"
+  "strings"
+  "sync"
+  "time"
+)
+
+// backupTimeFormat is the layout of the timestamp in the names of rotated log
+// files. It does not contain colons, so that it can be used on any platform.
+// The timestamp is always in UTC, so that it can be parsed back.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileSinkOptions holds the options used when creating a new FileSink.
+type FileSinkOptions struct {
+  // Filename is the file messages are written to. It is created if it does
+  // not exist, and appended to if it does.
+  Filename string
+
+  // Encoder is the encoder used to write messages. It defaults to plain text
+  // without colors.
+  Encoder Encoder
+
+  // Level is the level threshold of the sink. It defaults to L_DEBUG.
+  Level LogFlag
+
+  // MaxSize is the size in bytes at which the file is rotated. If it is zero,
+  // the file is not rotated by size.
+  MaxSize int64
+
+  // Interval is the time after which the file is rotated. If it is zero, the
+  // file is not rotated by time.
+  Interval time.Duration
+
+  // Compress gzips the rotated files.
+  Compress bool
+
+  // MaxBackups is the maximum number of rotated files that are kept, and
+  // MaxAge is the maximum age of the rotated files that are kept. If either
+  // is zero, rotated files are not removed for that reason.
+  MaxBackups int
+  MaxAge time.Duration
+
+  // Clock returns the current time. It defaults to time.Now.
+  Clock func() time.Time
+}
+
+// FileSink is a Sink which writes messages to a file on disk, without ANSI
+// codes. The file is rotated when it reaches a size limit or when a time
+// interval has passed. Rotated files are renamed using the time of the
+// rotation, e.g. "robot-2017-06-01T12-00-00.000.log", and can be compressed and
+// pruned by count or age.
+type FileSink struct {
+  mu sync.Mutex
+
+  opts FileSinkOptions
+
+  file *os.File
+  w *bufio.Writer
+
+  // closed is set when the sink is closed. The file is also nil when it could
+  // not be opened again after a rotation, in which case it is opened again on
+  // the next write.
+  closed bool
+
+  // size is the size of the current file, and opened is the time it was
+  // opened.
+  size int64
+  opened time.Time
+
+  // mill serializes the compression and removal of rotated files, which runs
+  // in the background, and wg waits for it when the sink is closed. millErr
+  // holds the first error of the compression, returned by Sync or Close.
+  mill sync.Mutex
+  millErr error
+  wg sync.WaitGroup
+}
+
+// NewFileSink returns a new FileSink configured with the options opts. It
+// returns an error if the file cannot be opened.
+func NewFileSink(opts FileSinkOptions) (*FileSink, error) {
+  if opts.Encoder == nil {
+    opts.Encoder = NewTextEncoder(false)
+  }
+  if opts.Level == 0 {
+    opts.Level = L_DEBUG
+  }
+  if opts.Clock == nil {
+    opts.Clock = time.Now
+  }
+
+  fs := &FileSink{opts: opts}
+
+  if err := fs.open(); err != nil {
+    return nil, err
+  }
+
+  return fs, nil
+}
+
+// open opens the log file for appending.
+func (fs *FileSink) open() error {
+  if err := os.MkdirAll(filepath.Dir(fs.opts.Filename), 0755); err != nil {
+    return err
+  }
+
+  f, err := os.OpenFile(fs.opts.Filename, os.O_CREATE | os.O_WRONLY | os.O_APPEND, 0644)
+  if err != nil {
+    return err
+  }
+
+  info, err := f.Stat()
+  if err != nil {
+    f.Close()
+    return err
+  }
+
+  fs.file = f
+  fs.w = bufio.NewWriter(f)
+  fs.size = info.Size()
+  fs.opened = fs.opts.Clock()
+
+  return nil
+}
+
+// Enabled is the implementation of the Sink interface.
+func (fs *FileSink) Enabled(flags LogFlag) bool {
+  return atLevel(flags, fs.opts.Level)
+}
+
+// Emit is the implementation of the Sink interface.
+func (fs *FileSink) Emit(msg Message, update bool) error {
+  b, err := fs.opts.Encoder.Encode(msg, update)
+  if err != nil {
+    return err
+  }
+
+  // Text encoders may still produce colors, which do not belong in a file.
+  b = append([]byte(stripANSI(string(b))), '\n')
+
+  fs.mu.Lock()
+  defer fs.mu.Unlock()
+
+  if err := fs.reopen(); err != nil {
+    return err
+  }
+
+  if fs.shouldRotate(int64(len(b))) {
+    if err := fs.rotate(); err != nil {
+      return err
+    }
+  }
+
+  n, err := fs.w.Write(b)
+  fs.size = fs.size + int64(n)
+
+  return err
+}
+
+// shouldRotate reports whether the file must be rotated before writing n more
+// bytes to it.
+func (fs *FileSink) shouldRotate(n int64) bool {
+  if fs.opts.MaxSize > 0 && fs.size > 0 && fs.size + n > fs.opts.MaxSize {
+    return true
+  }
+  if fs.opts.Interval > 0 && fs.opts.Clock().Sub(fs.opened) >= fs.opts.Interval {
+    return true
+  }
+  return false
+}
+
+// Rotate closes the current file, renames it using the current time and opens
+// a new file.
+func (fs *FileSink) Rotate() error {
+  fs.mu.Lock()
+  defer fs.mu.Unlock()
+
+  if err := fs.reopen(); err != nil {
+    return err
+  }
+
+  return fs.rotate()
+}
+
+// reopen opens the log file again if it could not be opened after a rotation.
+// It returns os.ErrClosed if the sink is closed.
+func (fs *FileSink) reopen() error {
+  if fs.closed {
+    return os.ErrClosed
+  }
+  if fs.file == nil {
+    return fs.open()
+  }
+  return nil
+}
+
+// rotate renames the current file and opens a new one. If the file cannot be
+// renamed, the current file is opened again, so that the sink keeps logging.
+func (fs *FileSink) rotate() error {
+  if err := fs.close(); err != nil {
+    fs.open()
+    return err
+  }
+
+  name := fs.backupName(fs.opts.Clock())
+
+  if err := os.Rename(fs.opts.Filename, name); err != nil && !os.IsNotExist(err) {
+    fs.open()
+    return err
+  }
+
+  if err := fs.open(); err != nil {
+    return err
+  }
+
+  // Compress and prune the rotated files in the background.
+  fs.wg.Add(1)
+  go func() {
+    defer fs.wg.Done()
+
+    fs.mill.Lock()
+    defer fs.mill.Unlock()
+
+    // The file may already be pruned by a later rotation.
+    if fs.opts.Compress {
+      if err := compressFile(name); err != nil && !os.IsNotExist(err) && fs.millErr == nil {
+        fs.millErr = err
+      }
+    }
+    fs.prune()
+  }()
+
+  return nil
+}
+
+// split returns the name of the log file without its extension, and its
+// extension.
+func (fs *FileSink) split() (prefix string, ext string) {
+  ext = filepath.Ext(fs.opts.Filename)
+  prefix = strings.TrimSuffix(fs.opts.Filename, ext)
+  return
+}
+
+// backupName returns the name of a file rotated at the time t. A counter is
+// added to the name if a file rotated at the same time already exists.
+func (fs *FileSink) backupName(t time.Time) string {
+  prefix, ext := fs.split()
+  stamp := t.UTC().Format(backupTimeFormat)
+
+  name := prefix + "-" + stamp + ext
+  for i := 1; fileExists(name) || fileExists(name + ".gz"); i++ {
+    name = fmt.Sprintf("%s-%s.%d%s", prefix, stamp, i, ext)
+  }
+
+  return name
+}
+
+// fileExists reports whether the file name exists.
+func fileExists(name string) bool {
+  _, err := os.Stat(name)
+  return err == nil
+}
+
+// backup is a rotated log file.
+type backup struct {
+  name string
+  t time.Time
+}
+
+// backups returns the rotated log files, newest first.
+func (fs *FileSink) backups() []backup {
+  prefix, ext := fs.split()
+
+  // The directory is listed rather than globbed, since the name of the file
+  // may contain glob patterns.
+  dir := filepath.Dir(prefix)
+  base := filepath.Base(prefix) + "-"
+
+  infos, _ := ioutil.ReadDir(dir)
+
+  var backups []backup
+
+  for _, info := range infos {
+    stamp := strings.TrimSuffix(info.Name(), ".gz")
+    if info.IsDir() || !strings.HasPrefix(stamp, base) || !strings.HasSuffix(stamp, ext) {
+      continue
+    }
+
+    stamp = strings.TrimSuffix(strings.TrimPrefix(stamp, base), ext)
+    name := filepath.Join(dir, info.Name())
+
+    // Remove the counter added to files rotated at the same time.
+    if len(stamp) > len(backupTimeFormat) {
+      stamp = stamp[:len(backupTimeFormat)]
+    }
+
+    t, err := time.Parse(backupTimeFormat, stamp)
+    if err != nil {
+      continue
+    }
+
+    backups = append(backups, backup{name, t})
+  }
+
+  sort.SliceStable(backups, func(i, j int) bool {
+    return backups[i].t.After(backups[j].t)
+  })
+
+  return backups
+}
+
+// prune removes the rotated files beyond the maximum count or age.
+func (fs *FileSink) prune() {
+  if fs.opts.MaxBackups <= 0 && fs.opts.MaxAge <= 0 {
+    return
+  }
+
+  var cutoff time.Time
+  if fs.opts.MaxAge > 0 {
+    cutoff = fs.opts.Clock().Add(-fs.opts.MaxAge)
+  }
+
+  for i, b := range fs.backups() {
+    if fs.opts.MaxBackups > 0 && i >= fs.opts.MaxBackups {
+      os.Remove(b.name)
+    } else if fs.opts.MaxAge > 0 && b.t.Before(cutoff) {
+      os.Remove(b.name)
+    }
+  }
+}
+
+// compressFile gzips the file name to name.gz and removes the original file.
+func compressFile(name string) error {
+  src, err := os.Open(name)
+  if err != nil {
+    return err
+  }
+  defer src.Close()
+
+  dst, err := os.OpenFile(name + ".gz", os.O_CREATE | os.O_WRONLY | os.O_TRUNC, 0644)
+  if err != nil {
+    return err
+  }
+
+  zw := gzip.NewWriter(dst)
+
+  if _, err = io.Copy(zw, src); err == nil {
+    err = zw.Close()
+  }
+  if cerr := dst.Close(); err == nil {
+    err = cerr
+  }
+
+  if err != nil {
+    os.Remove(name + ".gz")
+    return err
+  }
+
+  src.Close()
+  return os.Remove(name)
+}
+
+// Sync flushes the buffered data to the file and commits the file to disk. It
+// also returns the error of a failed compression of a rotated file.
+func (fs *FileSink) Sync() error {
+  if err := fs.compressErr(); err != nil {
+    return err
+  }
+
+  fs.mu.Lock()
+  defer fs.mu.Unlock()
+
+  if fs.file == nil {
+    return nil
+  }
+
+  if err := fs.w.Flush(); err != nil {
+    return err
+  }
+
+  return fs.file.Sync()
+}
+
+// compressErr returns and clears the error of a failed compression.
+func (fs *FileSink) compressErr() error {
+  fs.mill.Lock()
+  defer fs.mill.Unlock()
+
+  err := fs.millErr
+  fs.millErr = nil
+  return err
+}
+
+// Close flushes the buffered data and closes the file. It waits for any rotated
+// files to be compressed and pruned, and returns the error of a failed
+// compression.
+func (fs *FileSink) Close() error {
+  fs.mu.Lock()
+  err := fs.close()
+  fs.closed = true
+  fs.mu.Unlock()
+
+  fs.wg.Wait()
+
+  if cerr := fs.compressErr(); err == nil {
+    err = cerr
+  }
+
+  return err
+}
+
+// close flushes and closes the current file.
+func (fs *FileSink) close() error {
+  if fs.file == nil {
+    return nil
+  }
+
+  err := fs.w.Flush()
+
+  if serr := fs.file.Sync(); err == nil {
+    err = serr
+  }
+  if cerr := fs.file.Close(); err == nil {
+    err = cerr
+  }
+
+  fs.file = nil
+  fs.w = nil
+
+  return err
+}
@@ -2,6 +2,9 @@ package robologger
 
 import (
   "fmt"
+  "io"
+  "os"
+  "reflect"
   "sync"
 )
 
@@ -18,6 +21,26 @@ import (
 type History struct {
   mu sync.Mutex
   messages []Message
+
+  // pr is the printer used to rewrite updated messages.
+  pr *printer
+
+  // streams holds the output stream each message was printed to, and screens
+  // holds the screen of each message.
+  streams map[Message]io.Writer
+  screens map[Message]int
+
+  // known holds the streams seen by the history, and their screens.
+  known []screen
+}
+
+// screen is an output stream and the id of the screen it is printed to.
+// Streams which print to the same screen, e.g. stdout and stderr on the same
+// terminal, have the same id.
+type screen struct {
+  w io.Writer
+  info os.FileInfo
+  id int
 }
 
 // NewHistory returns a new, empty History object for use in the logger.
@@ -47,15 +70,38 @@ func (h *History) Update(msg Message) {
   h.mu.Lock()
 	defer h.mu.Unlock()
 
-  offset := h.getPrintOffset(msg)
-
   // If the message is not part of this log's history, we default to using the
   // last message in the log.
   if msg == nil {
     msg = h.Get(-1)
-    offset = msg.getPrintLength()
   }
 
+  // A history that is not attached to a logger has nothing to redraw.
+  if h.pr == nil {
+    return
+  }
+
+  pr, term := h.pr, h.pr.term
+
+  out := h.stream(msg)
+  pr.SetOutput(out)
+
+  // Encoded output is append-only, so the update is written as a new event
+  // instead of redrawing the message in place.
+  if pr.enc != nil {
+    pr.encode(msg, true)
+    return
+  }
+
+  // Output which is not a terminal cannot be redrawn, so the message is
+  // printed again on a new line when it changes.
+  if !pr.interactive(out) {
+    pr.appendUpdate(msg)
+    return
+  }
+
+  sid := h.screenID(out)
+  offset := h.getPrintOffset(msg, sid)
   ll := msg.getPrintLength()
 
   term.SaveCursorPosition()
@@ -67,7 +113,7 @@ func (h *History) Update(msg Message) {
   n, _ := pr.WriteMessage(msg)
 
   // We include a newline for all log messages.
-  fmt.Print("\n")
+  fmt.Fprint(pr.out, "\n")
 
   // If the new message length does not equal the previous line length, the log
   // is rewritten from the updated message down.
@@ -77,24 +123,194 @@ func (h *History) Update(msg Message) {
     index, _ := h.Find(msg)
 
     for i := index + 1; i < len(h.messages); i++ {
-      pr.WriteMessage(h.messages[i])
+      m := h.messages[i]
+
+      // Messages which were not printed to the same screen are skipped.
+      if m.getPrintLength() == 0 || h.screenOf(m) != sid {
+        continue
+      }
+
+      pr.SetOutput(h.stream(m))
+      pr.WriteMessage(m)
 
-      fmt.Print("\n")
+      fmt.Fprint(pr.out, "\n")
     }
+
+    pr.SetOutput(out)
+
+    // The cursor is now below the last message. The lines left over from a
+    // longer message are cleared, and the saved position, which is where the
+    // bottom of the log used to be, is not restored.
+    term.ClearToEnd()
+    term.ShowCursor()
+    return
   }
 
   term.ShowCursor()
   term.RestoreCursorPosition()
 }
 
+// reflow recomputes the number of lines taken by each message printed to the
+// screen of out, after the terminal was resized from the width old to the width
+// of the printer, and redraws the live region at the new width. The live region
+// starts at the earliest Status or Progress message which is still on the
+// screen, i.e. within the last rows lines, and below the last prompt. If rows is
+// zero, the height of the screen is unknown.
+func (h *History) reflow(old int, rows int, out io.Writer) {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+
+  if h.pr == nil {
+    return
+  }
+
+  pr, term := h.pr, h.pr.term
+
+  if pr.enc != nil || !pr.interactive(out) {
+    return
+  }
+
+  pr.SetOutput(out)
+
+  sid := h.screenID(out)
+  start, offset, total := -1, 0, 0
+  prompt := false
+
+  for i := len(h.messages) - 1; i >= 0; i-- {
+    m := h.messages[i]
+
+    if m.getPrintLength() == 0 || h.screenOf(m) != sid {
+      continue
+    }
+
+    // The terminal rewraps the lines which are already on the screen.
+    _, widths := pr.wrap(m, old)
+    n := reflowedLines(widths, pr.length)
+    m.setPrintLength(n)
+    total = total + n
+
+    // Redrawing above a prompt would erase the input typed after it.
+    if _, ok := m.(*PromptMessage); ok {
+      prompt = true
+    }
+
+    if prompt || (rows > 0 && total >= rows) {
+      continue
+    }
+
+    switch m.(type) {
+    case *StatusMessage, *ProgressMessage:
+      start, offset = i, total
+    }
+  }
+
+  if start < 0 {
+    return
+  }
+
+  term.HideCursor()
+  term.MoveToBeginning()
+  term.MoveUp(offset)
+  term.ClearToEnd()
+
+  for _, m := range h.messages[start:] {
+    if m.getPrintLength() == 0 || h.screenOf(m) != sid {
+      continue
+    }
+
+    pr.SetOutput(h.stream(m))
+
+    n, _ := pr.WriteMessage(m)
+    m.setPrintLength(n)
+
+    fmt.Fprint(pr.out, "\n")
+  }
+
+  pr.SetOutput(out)
+
+  term.ShowCursor()
+}
+
+// setStream records the output stream the message was printed to.
+func (h *History) setStream(msg Message, w io.Writer) {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+
+  if h.streams == nil {
+    h.streams = make(map[Message]io.Writer)
+    h.screens = make(map[Message]int)
+  }
+  h.streams[msg] = w
+  h.screens[msg] = h.screenID(w)
+}
+
+// stream returns the output stream the message was printed to. Messages which
+// were not printed by a logger use the output stream of the printer.
+func (h *History) stream(msg Message) io.Writer {
+  if w, ok := h.streams[msg]; ok {
+    return w
+  }
+  return h.pr.out
+}
+
+// screenOf returns the id of the screen the message was printed to.
+func (h *History) screenOf(msg Message) int {
+  if id, ok := h.screens[msg]; ok {
+    return id
+  }
+  return h.screenID(h.pr.out)
+}
+
+// screenID returns the id of the screen the output stream w is printed to. The
+// streams which are files are only checked once, when they are first seen, so
+// that redrawing a message does not check every file in the history.
+func (h *History) screenID(w io.Writer) int {
+  for _, s := range h.known {
+    if sameWriter(s.w, w) {
+      return s.id
+    }
+  }
+
+  id := len(h.known)
+
+  var info os.FileInfo
+
+  if f, ok := w.(*os.File); ok {
+    if fi, err := f.Stat(); err == nil {
+      info = fi
+
+      for _, s := range h.known {
+        if s.info != nil && os.SameFile(s.info, fi) {
+          id = s.id
+          break
+        }
+      }
+    }
+  }
+
+  h.known = append(h.known, screen{w, info, id})
+
+  return id
+}
+
+// sameWriter reports whether a and b are the same output stream.
+func sameWriter(a, b io.Writer) bool {
+  return reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.TypeOf(a).Comparable() && a == b
+}
+
 // getPrintOffset returns the number of printed lines from the bottom of the
-// log.
-func (h History) getPrintOffset(msg Message) int {
+// log, counting only the lines printed to the screen sid.
+func (h *History) getPrintOffset(msg Message, sid int) int {
   var offset int
 
   for i := len(h.messages) - 1; i >= 0; i-- {
-    offset = offset + h.messages[i].getPrintLength()
-    if msg == h.messages[i] {
+    m := h.messages[i]
+
+    if m == msg || h.screenOf(m) == sid {
+      offset = offset + m.getPrintLength()
+    }
+
+    if msg == m {
       break
     }
   }
@@ -103,7 +319,7 @@ func (h History) getPrintOffset(msg Message) int {
 }
 
 // Get returns the message in the history referenced by the index.
-func (h History) Get(index int) Message {
+func (h *History) Get(index int) Message {
   if index < 0 {
     index = len(h.messages) + index // (index * -1)
   }
@@ -117,7 +333,7 @@ func (h History) Get(index int) Message {
 
 // Find finds a message in the history and returns the pointer to that message.
 // Returns nil if the message is not in the history.
-func (h History) Find(msg Message) (int, Message) {
+func (h *History) Find(msg Message) (int, Message) {
   for i, m := range h.messages {
     if msg == m {
       return i, m
@@ -126,3 +342,37 @@ func (h History) Find(msg Message) (int, Message) {
 
   return -1, nil
 }
+
+// Count returns the number of log messages in the history which have any of
+// the flags set.
+func (h *History) Count(flags LogFlag) int {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+
+  var n int
+
+  for _, m := range h.messages {
+    if lm, ok := m.(*LogMessage); ok && lm.flags&flags != 0 {
+      n = n + 1
+    }
+  }
+
+  return n
+}
+
+// Filter returns the messages in the history for which the function f returns
+// true, e.g. all messages with a particular structured field.
+func (h *History) Filter(f func(Message) bool) []Message {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+
+  var messages []Message
+
+  for _, m := range h.messages {
+    if f(m) {
+      messages = append(messages, m)
+    }
+  }
+
+  return messages
+}
@@ -0,0 +1,186 @@
+package robologger
+
+import (
+  "bytes"
+  "encoding/binary"
+  "errors"
+  "fmt"
+  "net"
+  "os"
+  "path/filepath"
+  "strings"
+  "sync"
+  "syscall"
+)
+
+// journaldSocket is the socket of the native protocol of systemd-journald.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// JournaldSinkOptions holds the options used when creating a new JournaldSink.
+type JournaldSinkOptions struct {
+  // Path is the socket of the journal. It defaults to
+  // /run/systemd/journal/socket.
+  Path string
+
+  // Identifier is sent as the SYSLOG_IDENTIFIER of the messages. It defaults
+  // to the name of the program.
+  Identifier string
+
+  // Level is the level threshold of the sink. It defaults to L_DEBUG.
+  Level LogFlag
+}
+
+// JournaldSink is a Sink which sends messages to systemd-journald using its
+// native protocol. Each message is sent as a datagram holding the PRIORITY,
+// MESSAGE and CODE_FILE, CODE_LINE and CODE_FUNC of the message, along with the
+// structured fields of the message using upper-cased names.
+//
+// Entries which are too large for a datagram are written to a temporary file,
+// and the file descriptor is sent to the journal instead.
+type JournaldSink struct {
+  mu sync.Mutex
+
+  opts JournaldSinkOptions
+
+  conn *net.UnixConn
+  addr *net.UnixAddr
+}
+
+// NewJournaldSink returns a new JournaldSink configured with the options opts.
+// It returns an error if the socket of the journal does not exist.
+func NewJournaldSink(opts JournaldSinkOptions) (*JournaldSink, error) {
+  if opts.Path == "" {
+    opts.Path = journaldSocket
+  }
+  if opts.Identifier == "" {
+    opts.Identifier = filepath.Base(os.Args[0])
+  }
+  if opts.Level == 0 {
+    opts.Level = L_DEBUG
+  }
+
+  if _, err := os.Stat(opts.Path); err != nil {
+    return nil, err
+  }
+
+  // The socket is not connected, so that the sink keeps working when the
+  // journal is restarted.
+  conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: "", Net: "unixgram"})
+  if err != nil {
+    return nil, err
+  }
+
+  return &JournaldSink{
+    opts: opts,
+    conn: conn,
+    addr: &net.UnixAddr{Name: opts.Path, Net: "unixgram"},
+  }, nil
+}
+
+// Enabled is the implementation of the Sink interface.
+func (s *JournaldSink) Enabled(flags LogFlag) bool {
+  return atLevel(flags, s.opts.Level)
+}
+
+// Emit is the implementation of the Sink interface.
+func (s *JournaldSink) Emit(msg Message, update bool) error {
+  b := s.format(msg)
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  if s.conn == nil {
+    return os.ErrClosed
+  }
+
+  _, _, err := s.conn.WriteMsgUnix(b, nil, s.addr)
+  if err == nil {
+    return nil
+  }
+
+  // Large entries are sent using a file descriptor.
+  if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+    return s.sendFD(b)
+  }
+
+  return err
+}
+
+// format returns the message encoded using the native journal protocol.
+func (s *JournaldSink) format(msg Message) []byte {
+  var buf bytes.Buffer
+
+  writeJournalField(&buf, "PRIORITY", fmt.Sprint(syslogSeverities[messageLevel(msg)]))
+  writeJournalField(&buf, "MESSAGE", msg.String())
+  writeJournalField(&buf, "SYSLOG_IDENTIFIER", s.opts.Identifier)
+
+  if lm, ok := msg.(*LogMessage); ok && lm.caller != nil {
+    writeJournalField(&buf, "CODE_FILE", lm.caller.File)
+    writeJournalField(&buf, "CODE_LINE", fmt.Sprint(lm.caller.Line))
+    writeJournalField(&buf, "CODE_FUNC", lm.caller.Function)
+  }
+
+  for _, field := range msg.Fields() {
+    writeJournalField(&buf, journalFieldName(field.Key), fmt.Sprint(field.Value))
+  }
+
+  return buf.Bytes()
+}
+
+// writeJournalField writes a field to buf. Values without newlines are written
+// as "NAME=value\n". Values with newlines are written as the name, a newline,
+// the length of the value as a little endian 64 bit integer, the value and a
+// newline.
+func writeJournalField(buf *bytes.Buffer, name string, value string) {
+  if !strings.ContainsRune(value, '\n') {
+    buf.WriteString(name + "=" + value + "\n")
+    return
+  }
+
+  buf.WriteString(name + "\n")
+  binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+  buf.WriteString(value + "\n")
+}
+
+// journalFieldName returns the key of a structured field as a journal field
+// name, which only contains upper case letters, digits and underscores, and
+// does not start with an underscore or a digit.
+func journalFieldName(key string) string {
+  name := strings.Map(func(r rune) rune {
+    switch {
+    case r >= 'a' && r <= 'z':
+      return r - 'a' + 'A'
+    case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+      return r
+    default:
+      return '_'
+    }
+  }, key)
+
+  // Fields starting with an underscore are reserved for the journal.
+  name = strings.TrimLeft(name, "_")
+
+  if name == "" || name[0] >= '0' && name[0] <= '9' {
+    name = "F_" + name
+  }
+  if len(name) > 64 {
+    name = name[:64]
+  }
+
+  return name
+}
+
+// Close closes the socket of the sink.
+func (s *JournaldSink) Close() error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  if s.conn == nil {
+    return nil
+  }
+
+  err := s.conn.Close()
+  s.conn = nil
+
+  return err
+}
@@ -0,0 +1,36 @@
+package robologger
+
+import (
+  "io/ioutil"
+  "os"
+  "syscall"
+)
+
+// sendFD writes the entry b to an unlinked temporary file and sends the file
+// descriptor of the file to the journal, which reads the entry from it. The
+// file is created in /dev/shm, which is kept in memory, if it exists.
+func (s *JournaldSink) sendFD(b []byte) error {
+  dir := "/dev/shm"
+  if _, err := os.Stat(dir); err != nil {
+    dir = os.TempDir()
+  }
+
+  f, err := ioutil.TempFile(dir, "robologger-journal")
+  if err != nil {
+    return err
+  }
+  defer f.Close()
+
+  if err := os.Remove(f.Name()); err != nil {
+    return err
+  }
+
+  if _, err := f.Write(b); err != nil {
+    return err
+  }
+
+  rights := syscall.UnixRights(int(f.Fd()))
+
+  _, _, err = s.conn.WriteMsgUnix(nil, rights, s.addr)
+  return err
+}
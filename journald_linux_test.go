@@ -0,0 +1,95 @@
+package robologger
+
+import (
+  "io/ioutil"
+  "net"
+  "os"
+  "path/filepath"
+  "strings"
+  "syscall"
+  "testing"
+  "time"
+)
+
+func TestJournaldSink(t *testing.T) {
+  dir, err := ioutil.TempDir("", "robologger")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+
+  path := filepath.Join(dir, "socket")
+
+  conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer conn.Close()
+  conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+  s, err := NewJournaldSink(JournaldSinkOptions{Path: path, Identifier: "arm"})
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer s.Close()
+
+  // read returns the next entry, reading it from the file descriptor if one
+  // was sent.
+  read := func() string {
+    b := make([]byte, 4096)
+    oob := make([]byte, 1024)
+
+    n, oobn, _, _, err := conn.ReadMsgUnix(b, oob)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if oobn == 0 {
+      return string(b[:n])
+    }
+
+    msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+    if err != nil {
+      t.Fatal(err)
+    }
+    fds, err := syscall.ParseUnixRights(&msgs[0])
+    if err != nil {
+      t.Fatal(err)
+    }
+
+    f := os.NewFile(uintptr(fds[0]), "entry")
+    defer f.Close()
+
+    f.Seek(0, 0)
+    entry, err := ioutil.ReadAll(f)
+    if err != nil {
+      t.Fatal(err)
+    }
+    return string(entry)
+  }
+
+  msg := NewLogMessage(L_ERROR, nil, "Motor stalled.")
+  msg.setFields(newFields("motor-id", 3, "trace", "line 1\nline 2"))
+  msg.caller = &Caller{"arm.go", 42, "main.move"}
+
+  if err := s.Emit(msg, false); err != nil {
+    t.Fatal(err)
+  }
+
+  want := "PRIORITY=3\nMESSAGE=Motor stalled.\nSYSLOG_IDENTIFIER=arm\n" +
+    "CODE_FILE=arm.go\nCODE_LINE=42\nCODE_FUNC=main.move\nMOTOR_ID=3\n" +
+    "TRACE\n\x0d\x00\x00\x00\x00\x00\x00\x00line 1\nline 2\n"
+  if got := read(); got != want {
+    t.Errorf("unexpected journal entry\n got: %q\nwant: %q", got, want)
+  }
+
+  // Entries which are too large for a datagram are sent as a file descriptor.
+  large := strings.Repeat("robot ", 1 << 17)
+
+  if err := s.Emit(NewLogMessage(L_INFO, nil, large), false); err != nil {
+    t.Fatal(err)
+  }
+
+  if got := read(); !strings.HasPrefix(got, "PRIORITY=6\nMESSAGE=" + large + "\n") {
+    t.Errorf("unexpected large journal entry of %d bytes", len(got))
+  }
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package robologger
+
+import "errors"
+
+// sendFD is only supported on Linux, where the journal runs.
+func (s *JournaldSink) sendFD(b []byte) error {
+  return errors.New("robologger: entry is too large for the journal")
+}
@@ -1,7 +1,10 @@
 package robologger
 
-import "fmt"
-import "os"
+import (
+  "fmt"
+  "strings"
+  "time"
+)
 
 // LogFlag defines the log message flags.
 type LogFlag int
@@ -13,15 +16,82 @@ const (
   L_WARN
   L_INFO
   L_DEBUG
+
+  // L_PANIC is more severe than L_ERROR, but comes last so that the values of
+  // the other flags are unchanged.
+  L_PANIC
 )
 
+// severity returns the rank of the level flags used to compare it to a level
+// threshold, where lower is more severe. It is the value of the flags, except
+// for L_PANIC, which ranks between L_FATAL and L_ERROR.
+func (flags LogFlag) severity() int {
+  if flags == L_PANIC {
+    return int(L_FATAL) + 1
+  }
+  return int(flags)
+}
+
+// atLevel reports whether messages with the level flags are printed at the
+// level threshold level.
+func atLevel(flags LogFlag, level LogFlag) bool {
+  return flags.severity() <= level.severity()
+}
+
+// levelNames holds the names of the log levels, used when parsing and printing
+// a LogFlag.
+var levelNames = map[LogFlag]string{
+  L_PRINT: "print",
+  L_FATAL: "fatal",
+  L_PANIC: "panic",
+  L_ERROR: "error",
+  L_WARN:  "warn",
+  L_INFO:  "info",
+  L_DEBUG: "debug",
+}
+
+// String returns the name of the log level.
+func (flags LogFlag) String() string {
+  if s, ok := levelNames[flags]; ok {
+    return s
+  }
+  return fmt.Sprintf("LogFlag(%d)", int(flags))
+}
+
+// ParseLevel returns the LogFlag corresponding to the level name s, e.g.
+// "debug" or "WARN". It returns an error if s is not a known level.
+func ParseLevel(s string) (LogFlag, error) {
+  s = strings.ToLower(strings.TrimSpace(s))
+
+  if s == "warning" {
+    s = "warn"
+  }
+
+  for flags, name := range levelNames {
+    if name == s {
+      return flags, nil
+    }
+  }
+
+  return 0, fmt.Errorf("robologger: unknown level %q", s)
+}
+
 // LogMessage implements the Message interface.
 type LogMessage struct {
   // printLength refers to how many lines it takes up on the screen.
   printLength int
 
+  // fields are the structured fields attached to the message.
+  fields Fields
+
+  // timestamps hold the creation and update times of the message.
+  timestamps
+
   flags LogFlag
 
+  // caller is the location the message was logged from, if it was recorded.
+  caller *Caller
+
   format *string
   a []interface{}
 }
@@ -29,6 +99,7 @@ type LogMessage struct {
 // NewLogMessage returns a new Message.
 func NewLogMessage(flags LogFlag, format *string, a ...interface{}) *LogMessage {
   return &LogMessage{
+    timestamps: newTimestamps(time.Now()),
     flags: flags,
     format: format,
     a: a,
@@ -53,23 +124,48 @@ func (lm *LogMessage) setPrintLength(n int) {
   lm.printLength = n
 }
 
+// Fields returns the structured fields attached to the message.
+func (lm LogMessage) Fields() Fields {
+  return lm.fields
+}
+
+func (lm *LogMessage) setFields(fields Fields) {
+  lm.fields = fields
+}
+
+// Caller returns the location the message was logged from, or nil if the
+// caller was not recorded.
+func (lm LogMessage) Caller() *Caller {
+  return lm.caller
+}
+
 // FormatMessage adds a prefix to each line of a particular message type. If
 // the message has a type other than L_PRINT, we will have a colored prefix.
 func (lm LogMessage) Format() (fmsg string) {
+  return lm.formatText(false)
+}
+
+func (lm LogMessage) formatText(multiline bool) (fmsg string) {
   s := lm.String()
 
-  // Remove newlines from the args and from the format string.
-  s = removeNewlines(s)
+  // Remove newlines from the args and from the format string, unless they are
+  // kept for multi-line output.
+  s = cleanNewlines(s, multiline)
 
-  // Preferentially apply prefixes to the log messages based upon the severity
-  // of the message.
-  var prefix string
+  fmsg = lm.prefix() + s + lm.fields.Format()
+  return
+}
 
+// prefix returns the prefix of the message, based upon the severity of the
+// message.
+func (lm LogMessage) prefix() (prefix string) {
   switch {
   case lm.flags&L_PRINT != 0:
     prefix = "        "
   case lm.flags&L_FATAL != 0:
     prefix = Color(C_RED_FG) + "[FATAL] " + Color(C_RESET)
+  case lm.flags&L_PANIC != 0:
+    prefix = Color(C_RED_FG) + "[PANIC] " + Color(C_RESET)
   case lm.flags&L_ERROR != 0:
     prefix = Color(C_RED_FG) + "[ERROR] " + Color(C_RESET)
   case lm.flags&L_WARN != 0:
@@ -80,142 +176,182 @@ func (lm LogMessage) Format() (fmsg string) {
     prefix = Color(C_CYAN_FG) + "[DEBUG] " + Color(C_RESET)
   }
 
-  fmsg = prefix + s
   return
 }
 
-// The following functions are the standard functions that print messages to
-// the terminal without any formatting.
-func Print(args ...interface{}) {
-  msg := NewLogMessage(L_PRINT, nil, args...)
-  log.Add(msg)
+// log prints a log message if the level flags is enabled. Messages below the
+// level threshold of the logger are discarded before they are formatted or
+// added to the history.
+//
+// The log method must be called directly by the exported functions and
+// methods, so that the caller of those is found at a fixed depth in the stack.
+func (l *Logger) log(flags LogFlag, format *string, args ...interface{}) {
+  if !l.Enabled(flags) {
+    return
+  }
+
+  msg := NewLogMessage(flags, format, args...)
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+  if l.captureCallers() {
+    msg.caller = l.caller(2)
+  }
 
-  fmt.Print("\n")
+  l.output(msg, true)
 }
 
-func Fatal(args ...interface{}) {
-  msg := NewLogMessage(L_FATAL, nil, args...)
-  log.Add(msg)
+// The following methods are the standard methods that print messages to the
+// terminal without any formatting.
+func (l *Logger) Print(args ...interface{}) {
+  l.log(L_PRINT, nil, args...)
+}
 
-  pr.SetOutput(Stderr)
+func (l *Logger) Fatal(args ...interface{}) {
+  l.log(L_FATAL, nil, args...)
+  l.Sync()
+  exit(1)
+}
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func (l *Logger) Panic(args ...interface{}) {
+  l.log(L_PANIC, nil, args...)
+  panic(fmt.Sprint(args...))
+}
 
-  fmt.Print("\n")
-  os.Exit(1)
+func (l *Logger) Error(args ...interface{}) {
+  l.log(L_ERROR, nil, args...)
 }
 
-func Error(args ...interface{}) {
-  msg := NewLogMessage(L_ERROR, nil, args...)
-  log.Add(msg)
+func (l *Logger) Warn(args ...interface{}) {
+  l.log(L_WARN, nil, args...)
+}
 
-  pr.SetOutput(Stderr)
+func (l *Logger) Info(args ...interface{}) {
+  l.log(L_INFO, nil, args...)
+}
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func (l *Logger) Debug(args ...interface{}) {
+  l.log(L_DEBUG, nil, args...)
+}
 
-  fmt.Print("\n")
-  panic(fmt.Sprint(args...))
+// The following methods are the "formatted" methods that print messages using
+// a format string.
+func (l *Logger) Printf(format string, args ...interface{}) {
+  l.log(L_PRINT, &format, args...)
 }
 
-func Warn(args ...interface{}) {
-  msg := NewLogMessage(L_WARN, nil, args...)
-  log.Add(msg)
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+  l.log(L_FATAL, &format, args...)
+  l.Sync()
+  exit(1)
+}
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func (l *Logger) Panicf(format string, args ...interface{}) {
+  l.log(L_PANIC, &format, args...)
+  panic(fmt.Sprintf(format, args...))
+}
 
-  fmt.Print("\n")
+func (l *Logger) Errorf(format string, args ...interface{}) {
+  l.log(L_ERROR, &format, args...)
 }
 
-func Info(args ...interface{}) {
-  msg := NewLogMessage(L_INFO, nil, args...)
-  log.Add(msg)
+func (l *Logger) Warnf(format string, args ...interface{}) {
+  l.log(L_WARN, &format, args...)
+}
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func (l *Logger) Infof(format string, args ...interface{}) {
+  l.log(L_INFO, &format, args...)
+}
 
-  fmt.Print("\n")
+func (l *Logger) Debugf(format string, args ...interface{}) {
+  l.log(L_DEBUG, &format, args...)
 }
 
-func Debug(args ...interface{}) {
-  msg := NewLogMessage(L_DEBUG, nil, args...)
-  log.Add(msg)
+// Summary prints the number of errors and warnings that have been logged. It is
+// meant to be called at the end of a run.
+func (l *Logger) Summary() {
+  errors := l.history.Count(L_FATAL | L_PANIC | L_ERROR)
+  warnings := l.history.Count(L_WARN)
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+  s := fmt.Sprintf("%d error", errors)
+  if errors != 1 {
+    s = s + "s"
+  }
 
-  fmt.Print("\n")
+  s = s + fmt.Sprintf(", %d warning", warnings)
+  if warnings != 1 {
+    s = s + "s"
+  }
+
+  l.Print(s)
 }
 
-// The following functions are the "formatted" functions that print messages
-// using a format string.
-func Printf(format string, args ...interface{}) {
-  msg := NewLogMessage(L_PRINT, &format, args...)
-  log.Add(msg)
+// The following functions are the standard functions that print messages to
+// the terminal using the default logger.
+func Print(args ...interface{}) {
+  std.log(L_PRINT, nil, args...)
+}
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func Fatal(args ...interface{}) {
+  std.log(L_FATAL, nil, args...)
+  std.Sync()
+  exit(1)
+}
 
-  fmt.Print("\n")
+func Panic(args ...interface{}) {
+  std.log(L_PANIC, nil, args...)
+  panic(fmt.Sprint(args...))
 }
 
-func Fatalf(format string, args ...interface{}) {
-  msg := NewLogMessage(L_FATAL, &format, args...)
-  log.Add(msg)
+func Error(args ...interface{}) {
+  std.log(L_ERROR, nil, args...)
+}
 
-  pr.SetOutput(Stderr)
+func Warn(args ...interface{}) {
+  std.log(L_WARN, nil, args...)
+}
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func Info(args ...interface{}) {
+  std.log(L_INFO, nil, args...)
+}
 
-  fmt.Print("\n")
-  os.Exit(1)
+func Debug(args ...interface{}) {
+  std.log(L_DEBUG, nil, args...)
 }
 
-func Errorf(format string, args ...interface{}) {
-  msg := NewLogMessage(L_ERROR, &format, args...)
-  log.Add(msg)
+// Summary prints the number of errors and warnings logged by the default
+// logger.
+func Summary() {
+  std.Summary()
+}
 
-  pr.SetOutput(Stderr)
+// The following functions are the "formatted" functions that print messages
+// using a format string and the default logger.
+func Printf(format string, args ...interface{}) {
+  std.log(L_PRINT, &format, args...)
+}
 
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func Fatalf(format string, args ...interface{}) {
+  std.log(L_FATAL, &format, args...)
+  std.Sync()
+  exit(1)
+}
 
-  fmt.Print("\n")
+func Panicf(format string, args ...interface{}) {
+  std.log(L_PANIC, &format, args...)
   panic(fmt.Sprintf(format, args...))
 }
 
-func Warnf(format string, args ...interface{}) {
-  msg := NewLogMessage(L_WARN, &format, args...)
-  log.Add(msg)
-
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func Errorf(format string, args ...interface{}) {
+  std.log(L_ERROR, &format, args...)
+}
 
-  fmt.Print("\n")
+func Warnf(format string, args ...interface{}) {
+  std.log(L_WARN, &format, args...)
 }
 
 func Infof(format string, args ...interface{}) {
-  msg := NewLogMessage(L_INFO, &format, args...)
-  log.Add(msg)
-
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
-
-  fmt.Print("\n")
+  std.log(L_INFO, &format, args...)
 }
 
 func Debugf(format string, args ...interface{}) {
-  msg := NewLogMessage(L_DEBUG, &format, args...)
-  log.Add(msg)
-
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
-
-  fmt.Print("\n")
+  std.log(L_DEBUG, &format, args...)
 }
@@ -0,0 +1,450 @@
+package robologger
+
+import (
+  "bufio"
+  "fmt"
+  "io"
+  "os"
+  "os/signal"
+  "sync"
+  "time"
+)
+
+// LoggerOptions holds the options used when creating a new Logger. Any zero
+// values are replaced by the package defaults.
+type LoggerOptions struct {
+  // Stdin is the input source used to read responses to prompts.
+  Stdin io.Reader
+
+  // Stdout and Stderr are the output streams used for messages.
+  Stdout io.Writer
+  Stderr io.Writer
+
+  // PrintLength is the maximum length of a line in the terminal. If it is
+  // not set and Stdout is a terminal, the width of the terminal is used, and
+  // the logger follows the terminal when it is resized. Otherwise, it
+  // defaults to 80.
+  PrintLength int
+
+  // Flags are the printer flags, e.g. PR_NO_COLOR.
+  Flags int
+
+  // Level is the minimum level of the messages that are printed. If it is
+  // not set, the level is read from the ROBOLOG_LEVEL environment variable,
+  // and defaults to L_DEBUG.
+  Level LogFlag
+
+  // Encoder is the encoder used to write messages. If it is nil, messages are
+  // written as formatted text for the terminal.
+  Encoder Encoder
+
+  // Clock returns the current time, and is used to timestamp messages. It
+  // defaults to time.Now.
+  Clock func() time.Time
+
+  // Caller turns on the caller capture mode, which records the file, line
+  // and function of the call site of every log message.
+  Caller bool
+
+  // TerminalLevel is the level threshold of the terminal. It defaults to
+  // L_DEBUG, so that every message printed by the logger is shown.
+  TerminalLevel LogFlag
+
+  // Sinks are additional destinations messages are written to.
+  Sinks []Sink
+
+  // Routes maps levels to the output stream their messages are printed to.
+  // Levels which are not in Routes use the default routes, which print
+  // warnings and errors to Stderr and all other messages to Stdout.
+  Routes map[LogFlag]io.Writer
+}
+
+// Logger prints messages to the terminal. Each Logger owns its own History,
+// printer, Terminal, input source and output streams, so that separate parts
+// of a program can log independently of each other.
+type Logger struct {
+  *core
+
+  // fields are the structured fields added to every message printed by the
+  // logger.
+  fields Fields
+}
+
+// core holds the state of a logger which is shared with the loggers derived
+// from it using With.
+type core struct {
+  mu sync.Mutex
+
+  history *History
+  printer *printer
+  term *Terminal
+
+  // scanner reads lines from the input source.
+  scanner *bufio.Scanner
+
+  stdout io.Writer
+  stderr io.Writer
+
+  // routes maps each level to the output stream its messages are printed to.
+  routes map[LogFlag]io.Writer
+
+  // level is the level threshold. Messages with a level above the threshold
+  // are not printed.
+  level LogFlag
+
+  // clock returns the current time.
+  clock func() time.Time
+
+  // callers is true if the caller of each log message is recorded, and
+  // helpers holds the names of the functions marked as helpers.
+  callers bool
+  helpers map[string]struct{}
+
+  // sinks are the additional destinations messages are written to.
+  sinks []Sink
+
+  // resize receives the signals sent when the terminal is resized.
+  resize chan os.Signal
+}
+
+// NewLogger returns a new Logger configured with the options opts.
+func NewLogger(opts LoggerOptions) *Logger {
+  if opts.Stdin == nil {
+    opts.Stdin = Stdin
+  }
+  if opts.Stdout == nil {
+    opts.Stdout = Stdout
+  }
+  if opts.Stderr == nil {
+    opts.Stderr = Stderr
+  }
+
+  // tty is the terminal the print length is detected from.
+  var tty *os.File
+
+  if opts.PrintLength <= 0 {
+    opts.PrintLength = 80
+
+    if f, ok := opts.Stdout.(*os.File); ok {
+      if cols, _, ok := terminalSize(f.Fd()); ok {
+        opts.PrintLength, tty = cols, f
+      }
+    }
+  }
+  if opts.Level == 0 {
+    opts.Level = envLevel()
+  }
+  if opts.Clock == nil {
+    opts.Clock = time.Now
+  }
+  if opts.TerminalLevel == 0 {
+    opts.TerminalLevel = L_DEBUG
+  }
+
+  l := &Logger{
+    core: &core{
+      history: NewHistory(),
+      term: NewTerminal(ESC),
+      scanner: bufio.NewScanner(opts.Stdin),
+      stdout: opts.Stdout,
+      stderr: opts.Stderr,
+      level: opts.Level,
+      clock: opts.Clock,
+      callers: opts.Caller,
+      sinks: opts.Sinks,
+    },
+  }
+
+  l.routes = defaultRoutes(opts.Stdout, opts.Stderr)
+  for flags, w := range opts.Routes {
+    l.setRoute(flags, w)
+  }
+
+  l.term.in = opts.Stdin
+  l.term.out = opts.Stdout
+
+  l.printer = newPrinter(opts.Stdout, opts.PrintLength, opts.Flags)
+  l.printer.term = l.term
+  l.printer.enc = opts.Encoder
+  l.printer.start = opts.Clock()
+  l.printer.level = opts.TerminalLevel
+  l.history.pr = l.printer
+
+  if tty != nil {
+    l.watchResize(tty)
+  }
+
+  return l
+}
+
+// watchResize resizes the logger whenever the terminal f is resized.
+func (l *Logger) watchResize(f *os.File) {
+  c := make(chan os.Signal, 1)
+  notifyResize(c)
+
+  l.resize = c
+
+  go func() {
+    for range c {
+      if cols, rows, ok := terminalSize(f.Fd()); ok {
+        l.Resize(cols, rows)
+      }
+    }
+  }()
+}
+
+// stopResize stops following the size of the terminal.
+func (l *Logger) stopResize() {
+  if l.resize == nil {
+    return
+  }
+
+  signal.Stop(l.resize)
+  close(l.resize)
+  l.resize = nil
+}
+
+// Resize sets the size of the terminal to cols columns and rows rows, and
+// redraws the Status and Progress messages which are still on the screen at
+// the new width. If rows is zero, the height of the terminal is unknown.
+//
+// Resize is called automatically when the terminal is resized, if the print
+// length of the logger is detected from the terminal.
+func (l *Logger) Resize(cols int, rows int) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+
+  old := l.printer.length
+  if cols <= 0 || cols == old {
+    return
+  }
+
+  l.printer.SetPrintLength(cols)
+  l.history.reflow(old, rows, l.stdout)
+}
+
+// std is the default logger used by the package-level functions.
+var std = NewLogger(LoggerOptions{})
+
+// Default returns the default logger used by the package-level functions.
+func Default() *Logger {
+  return std
+}
+
+// SetDefault makes l the default logger used by the package-level functions.
+func SetDefault(l *Logger) {
+  std = l
+}
+
+// envLevel returns the level set by the ROBOLOG_LEVEL environment variable. If
+// the variable is not set or is not a valid level, L_DEBUG is returned.
+func envLevel() LogFlag {
+  level, err := ParseLevel(os.Getenv("ROBOLOG_LEVEL"))
+  if err != nil {
+    return L_DEBUG
+  }
+  return level
+}
+
+// SetLevel sets the level threshold of the logger. Messages with a level above
+// the threshold, e.g. L_DEBUG messages when the level is L_INFO, are skipped.
+func (l *Logger) SetLevel(level LogFlag) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.level = level
+}
+
+// Level returns the level threshold of the logger.
+func (l *Logger) Level() LogFlag {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  return l.level
+}
+
+// Enabled reports whether messages with the level flags are printed by the
+// logger. It can be used to avoid computing expensive arguments.
+func (l *Logger) Enabled(flags LogFlag) bool {
+  return atLevel(flags, l.Level())
+}
+
+// SetEncoder sets the encoder used by the default logger.
+func SetEncoder(enc Encoder) {
+  std.SetEncoder(enc)
+}
+
+// SetLevel sets the level threshold of the default logger.
+func SetLevel(level LogFlag) {
+  std.SetLevel(level)
+}
+
+// Enabled reports whether messages with the level flags are printed by the
+// default logger.
+func Enabled(flags LogFlag) bool {
+  return std.Enabled(flags)
+}
+
+// History returns the message history of the logger.
+func (l *Logger) History() *History {
+  return l.history
+}
+
+// Terminal returns the terminal used by the logger.
+func (l *Logger) Terminal() *Terminal {
+  return l.term
+}
+
+// SetPrinterFlags sets the flags for the printer.
+func (l *Logger) SetPrinterFlags(flags int) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.printer.flags = flags
+}
+
+// SetPrintLength sets the maximum message length for the printer.
+func (l *Logger) SetPrintLength(n int) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.printer.SetPrintLength(n)
+}
+
+// SetTerminalLevel sets the level threshold of the terminal. Messages above the
+// threshold are still added to the history and written to the sinks of the
+// logger, but are not printed to the terminal.
+func (l *Logger) SetTerminalLevel(level LogFlag) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.printer.level = level
+}
+
+// SetEncoder sets the encoder used to write messages, e.g. a JSONEncoder. If
+// enc is nil, messages are written as formatted text for the terminal.
+func (l *Logger) SetEncoder(enc Encoder) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.printer.SetEncoder(enc)
+}
+
+// SetOutput sets the output stream used for messages of every level.
+func (l *Logger) SetOutput(out io.Writer) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.stdout = out
+  l.stderr = out
+  l.routes = defaultRoutes(out, out)
+  l.printer.SetOutput(out)
+}
+
+// defaultRoutes returns the default routing table, which routes warnings and
+// errors to stderr and all other messages to stdout.
+func defaultRoutes(stdout, stderr io.Writer) map[LogFlag]io.Writer {
+  return map[LogFlag]io.Writer{
+    L_PRINT: stdout,
+    L_FATAL: stderr,
+    L_PANIC: stderr,
+    L_ERROR: stderr,
+    L_WARN:  stderr,
+    L_INFO:  stdout,
+    L_DEBUG: stdout,
+  }
+}
+
+// SetRoute routes the messages of the levels flags to the output stream w. The
+// flags may hold several levels, e.g. L_WARN | L_INFO.
+func (l *Logger) SetRoute(flags LogFlag, w io.Writer) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.setRoute(flags, w)
+}
+
+func (l *Logger) setRoute(flags LogFlag, w io.Writer) {
+  for level := range l.routes {
+    if flags&level != 0 {
+      l.routes[level] = w
+    }
+  }
+}
+
+// route returns the output stream that messages with the level flags are
+// printed to.
+func (l *Logger) route(flags LogFlag) io.Writer {
+  if w, ok := l.routes[flags]; ok {
+    return w
+  }
+  return l.stdout
+}
+
+// SetRoute routes the messages of the levels flags of the default logger to the
+// output stream w.
+func SetRoute(flags LogFlag, w io.Writer) {
+  std.SetRoute(flags, w)
+}
+
+// SetInput sets the input source used to read responses to prompts.
+func (l *Logger) SetInput(in io.Reader) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.scanner = bufio.NewScanner(in)
+  l.term.in = in
+}
+
+// ScanLine reads the next line of input from the logger's input source.
+func (l *Logger) ScanLine() string {
+  l.scanner.Scan()
+  return l.scanner.Text()
+}
+
+// output adds the message to the history and writes it to the printer. If
+// newline is true, a newline is written after the message.
+func (l *Logger) output(msg Message, newline bool) {
+  l.outputAt(msg, l.clock(), newline)
+}
+
+// outputAt is like output, but the message is timestamped with the time t. The
+// fields of the logger are added in front of the fields of the message.
+func (l *Logger) outputAt(msg Message, t time.Time, newline bool) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+
+  if fields := msg.Fields(); len(fields) > 0 {
+    msg.setFields(append(l.fields[:len(l.fields):len(l.fields)], fields...))
+  } else {
+    msg.setFields(l.fields)
+  }
+  msg.setTime(t)
+  l.history.Add(msg)
+
+  level := messageLevel(msg)
+
+  if l.printer.Enabled(level) {
+    out := l.route(level)
+
+    l.history.setStream(msg, out)
+    l.printer.SetOutput(out)
+
+    n, _ := l.printer.WriteMessage(msg)
+    msg.setPrintLength(n)
+
+    // Encoded messages already end with a newline.
+    if newline && l.printer.enc == nil {
+      fmt.Fprint(l.printer.out, "\n")
+    }
+  }
+
+  l.emit(msg, false)
+}
+
+// update applies the change to a message that has already been printed to the
+// terminal, and rewrites it.
+func (l *Logger) update(msg Message, change func()) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+
+  change()
+  msg.setUpdated(l.clock())
+
+  if l.printer.Enabled(messageLevel(msg)) {
+    l.history.Update(msg)
+  }
+
+  l.emit(msg, true)
+}
@@ -0,0 +1,187 @@
+package robologger
+
+import (
+  "bytes"
+  "strings"
+  "testing"
+)
+
+func TestLoggerSetLevel(t *testing.T) {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{Stdout: &buf, Stderr: &buf, Flags: PR_NO_COLOR})
+  l.SetLevel(L_WARN)
+
+  l.Debug("Debugging.")
+  l.Info("Ready.")
+  l.Warn("Low battery.")
+  l.Error("Motor stalled.")
+
+  s := buf.String()
+  for _, skipped := range []string{"Debugging.", "Ready."} {
+    if strings.Contains(s, skipped) {
+      t.Errorf("got %q in the output above the level threshold", skipped)
+    }
+  }
+  for _, printed := range []string{"Low battery.", "Motor stalled."} {
+    if !strings.Contains(s, printed) {
+      t.Errorf("got no %q in the output below the level threshold", printed)
+    }
+  }
+
+  // Skipped messages are not added to the history.
+  msgs := l.History().Filter(func(Message) bool { return true })
+  if len(msgs) != 2 {
+    t.Fatalf("got %d messages in the history, want 2", len(msgs))
+  }
+  if n := l.History().Count(L_DEBUG | L_INFO); n != 0 {
+    t.Errorf("got %d skipped messages in the history", n)
+  }
+}
+
+func TestLoggerEnabled(t *testing.T) {
+  l := NewLogger(LoggerOptions{Stdout: &bytes.Buffer{}, Level: L_INFO})
+
+  tests := []struct {
+    flags LogFlag
+    want bool
+  }{
+    {L_FATAL, true},
+    {L_PANIC, true},
+    {L_ERROR, true},
+    {L_WARN, true},
+    {L_INFO, true},
+    {L_DEBUG, false},
+  }
+
+  for _, test := range tests {
+    if got := l.Enabled(test.flags); got != test.want {
+      t.Errorf("Enabled(%v) = %v at level %v, want %v", test.flags, got, l.Level(), test.want)
+    }
+  }
+
+  // L_PANIC ranks between L_FATAL and L_ERROR.
+  l.SetLevel(L_PANIC)
+  if !l.Enabled(L_FATAL) || !l.Enabled(L_PANIC) || l.Enabled(L_ERROR) {
+    t.Errorf("got the wrong levels enabled at level %v", l.Level())
+  }
+  l.SetLevel(L_FATAL)
+  if l.Enabled(L_PANIC) {
+    t.Errorf("got %v enabled at level %v", L_PANIC, l.Level())
+  }
+}
+
+func TestLoggerEnvLevel(t *testing.T) {
+  t.Setenv("ROBOLOG_LEVEL", "warn")
+
+  if level := NewLogger(LoggerOptions{Stdout: &bytes.Buffer{}}).Level(); level != L_WARN {
+    t.Errorf("got level %v with ROBOLOG_LEVEL=warn, want %v", level, L_WARN)
+  }
+
+  // The level of the options takes precedence over the environment.
+  if level := NewLogger(LoggerOptions{Stdout: &bytes.Buffer{}, Level: L_ERROR}).Level(); level != L_ERROR {
+    t.Errorf("got level %v with ROBOLOG_LEVEL=warn and the level set, want %v", level, L_ERROR)
+  }
+
+  t.Setenv("ROBOLOG_LEVEL", "")
+
+  if level := NewLogger(LoggerOptions{Stdout: &bytes.Buffer{}}).Level(); level != L_DEBUG {
+    t.Errorf("got level %v without ROBOLOG_LEVEL, want %v", level, L_DEBUG)
+  }
+}
+
+func TestLoggerLevelStatus(t *testing.T) {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{Stdout: &buf, Stderr: &buf, Level: L_WARN})
+
+  update := l.Status("Homing.")
+  update("Homed.")
+
+  progress := l.Progress("Calibrating.")
+  progress(50, "Calibrating.")
+
+  if s := buf.String(); s != "" {
+    t.Errorf("got output %q below the level threshold", s)
+  }
+  if msgs := l.History().Filter(func(Message) bool { return true }); len(msgs) != 0 {
+    t.Errorf("got %d messages in the history below the level threshold", len(msgs))
+  }
+}
+
+func TestLoggerFieldsStatus(t *testing.T) {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdin: strings.NewReader("y\n"),
+    Stdout: &buf,
+    Stderr: &buf,
+    Flags: PR_NO_COLOR,
+  }).With("axis", 2)
+
+  l.Status("Homing.")
+  l.Progress("Calibrating.")(50, "Calibrating.")
+  l.Prompt(P_YES | P_NO, "Continue?")
+
+  for _, want := range []string{"Homing. axis=2", "Calibrating. axis=2", "Continue? axis=2 [yN]"} {
+    if !strings.Contains(buf.String(), want) {
+      t.Errorf("got output %q, want %q", buf.String(), want)
+    }
+  }
+}
+
+func TestLoggerAppendUpdates(t *testing.T) {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{Stdout: &buf, Flags: PR_NO_COLOR})
+
+  for i := 0; i < 10; i++ {
+    update := l.Progress("Calibrating.")
+    update(100, "Calibrating.")
+    l.Status("Homing.")("Homed.")
+  }
+
+  // Only the last Status and Progress message are kept.
+  if n := len(l.printer.last); n != 2 {
+    t.Errorf("got %d messages kept for updates, want 2", n)
+  }
+
+  // A Status and a Progress message are updated together.
+  buf.Reset()
+
+  status := l.Status("Homing.")
+  progress := l.Progress("Calibrating.")
+  for i := 0; i < 10; i++ {
+    status("Homing.")
+    progress(i, "Calibrating.")
+  }
+
+  if n := strings.Count(buf.String(), "\n"); n != 2 {
+    t.Errorf("got %d lines for unchanged updates, want 2:\n%s", n, buf.String())
+  }
+}
+
+func TestStreamSinkLevel(t *testing.T) {
+  s := NewStreamSink(&bytes.Buffer{}, nil, 0)
+
+  if !s.Enabled(L_PRINT) || !s.Enabled(L_DEBUG) {
+    t.Error("a sink with a zero level does not accept every message")
+  }
+
+  // SetLevel and Enabled can be called concurrently.
+  done := make(chan struct{})
+  go func() {
+    defer close(done)
+    for i := 0; i < 100; i++ {
+      s.SetLevel(L_WARN)
+    }
+  }()
+  for i := 0; i < 100; i++ {
+    s.Enabled(L_INFO)
+  }
+  <-done
+
+  if s.Enabled(L_INFO) || !s.Enabled(L_WARN) {
+    t.Error("the level set by SetLevel is not used")
+  }
+}
@@ -1,12 +1,21 @@
 package robologger
 
-import "fmt"
+import (
+  "fmt"
+  "time"
+)
 
 // ProgressMessage implements the Message interface.
 type ProgressMessage struct {
   // printLength refers to how many lines it takes up on the screen.
   printLength int
 
+  // fields are the structured fields attached to the message.
+  fields Fields
+
+  // timestamps hold the creation and update times of the message.
+  timestamps
+
   progress int
 
   a []interface{}
@@ -15,6 +24,7 @@ type ProgressMessage struct {
 // NewProgressMessage returns a new Message.
 func NewProgressMessage(a ...interface{}) *ProgressMessage {
   return &ProgressMessage{
+    timestamps: newTimestamps(time.Now()),
     a: a,
   }
 }
@@ -32,7 +42,22 @@ func (pm *ProgressMessage) setPrintLength(n int) {
   pm.printLength = n
 }
 
+// Fields returns the structured fields attached to the message.
+func (pm ProgressMessage) Fields() Fields {
+  return pm.fields
+}
+
+func (pm *ProgressMessage) setFields(fields Fields) {
+  pm.fields = fields
+}
+
 func (pm ProgressMessage) Format() (fmsg string) {
+  return pm.formatText(false)
+}
+
+// formatText formats the progress bar. A progress bar is always printed on a single
+// line, so newlines are removed even for multi-line output.
+func (pm ProgressMessage) formatText(multiline bool) (fmsg string) {
   s := pm.String()
 
   // Remove newlines from the args and from the format string.
@@ -78,11 +103,7 @@ func (pm ProgressMessage) Format() (fmsg string) {
   // print the progress bar in the nice color we have.
   // statusWidth := 80 - (40 + 21)
   // statusWidth := 19
-  if len(s) > 29 {
-    fmsg = fmsg + " " + string(s[:26]) + "..."
-  } else {
-    fmsg = fmsg + " " + s
-  }
+  fmsg = fmsg + " " + truncate(s, 29) + pm.fields.Format()
 
   // If the status message is longer than the width of the writer, we need to
   // truncate the output so that it all fits on one line.
@@ -92,26 +113,27 @@ func (pm ProgressMessage) Format() (fmsg string) {
   return
 }
 
-// Progress prints a progress bar to the terminal.
-func Progress(args ...interface{}) (func(int, ...interface{})) {
+// Progress prints a progress bar to the terminal. Progress bars have the L_INFO
+// level, so below that level threshold nothing is printed, and the returned
+// function does nothing.
+func (l *Logger) Progress(args ...interface{}) (func(int, ...interface{})) {
+  if !l.Enabled(L_INFO) {
+    return func(int, ...interface{}) {}
+  }
+
   // Create a blank message and add it to the history.
   msg := NewProgressMessage(args...)
-  log.Add(msg)
-
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
-
-  // We include a newline for all log messages.
-  fmt.Print("\n")
+  l.output(msg, true)
 
   // This function is returned to the user as a callable closure which will
   // update the status bar.
   Update := func (progress int, args ...interface{}) {
-    msg.progress = progress
-    msg.a = args
-
-    // Update the message in the log.
-    log.Update(msg)
+    // Update the message in the log. The message is changed while the logger
+    // is locked, since a resize of the terminal can redraw it at any time.
+    l.update(msg, func() {
+      msg.progress = progress
+      msg.a = args
+    })
   }
 
   // We call the update function once to print the message to the log.
@@ -119,3 +141,8 @@ func Progress(args ...interface{}) (func(int, ...interface{})) {
 
   return Update
 }
+
+// Progress prints a progress bar to the terminal using the default logger.
+func Progress(args ...interface{}) (func(int, ...interface{})) {
+  return std.Progress(args...)
+}
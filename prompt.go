@@ -1,6 +1,9 @@
 package robologger
 
-import "fmt"
+import (
+  "fmt"
+  "time"
+)
 
 // PromptFlag defines the prompt message flags.
 type PromptFlag int
@@ -18,6 +21,12 @@ type PromptMessage struct {
   // printLength refers to how many lines it takes up on the screen.
   printLength int
 
+  // fields are the structured fields attached to the message.
+  fields Fields
+
+  // timestamps hold the creation and update times of the message.
+  timestamps
+
   flags PromptFlag
 
   format *string
@@ -27,6 +36,7 @@ type PromptMessage struct {
 // NewPromptMessage returns a new Message.
 func NewPromptMessage(flags PromptFlag, format *string, a ...interface{}) *PromptMessage {
   return &PromptMessage{
+    timestamps: newTimestamps(time.Now()),
     flags: flags,
     format: format,
     a: a,
@@ -51,13 +61,30 @@ func (pm *PromptMessage) setPrintLength(n int) {
   pm.printLength = n
 }
 
+// Fields returns the structured fields attached to the message.
+func (pm PromptMessage) Fields() Fields {
+  return pm.fields
+}
+
+func (pm *PromptMessage) setFields(fields Fields) {
+  pm.fields = fields
+}
+
 func (pm PromptMessage) Format() (fmsg string) {
+  return pm.formatText(false)
+}
+
+func (pm PromptMessage) formatText(multiline bool) (fmsg string) {
   flags := pm.flags
 
   s := pm.String()
 
-  // Remove newlines from the args and from the format string.
-  s = removeNewlines(s)
+  // Remove newlines from the args and from the format string, unless they are
+  // kept for multi-line output.
+  s = cleanNewlines(s, multiline)
+
+  // Add the fields in front of the choices.
+  s = s + pm.fields.Format()
 
   // Add choices to the prompt.
   switch {
@@ -91,32 +118,32 @@ func (pm PromptMessage) Format() (fmsg string) {
   return s
 }
 
-func Prompt(flags PromptFlag, args ...interface{}) (string, Message) {
-  msg := NewPromptMessage(flags, nil, args...)
-  log.Add(msg)
-
-  // Do write stuff here.
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+// prompt prints a prompt message and reads the response from the input
+// source of the logger.
+func (l *Logger) prompt(flags PromptFlag, format *string, args ...interface{}) (string, Message) {
+  msg := NewPromptMessage(flags, format, args...)
+  l.output(msg, false)
 
   // Do read stuff here.
-  res := ScanLine()
+  res := l.ScanLine()
 
   return res, msg
 }
 
-func Promptf(flags PromptFlag, format string, args ...interface{}) (string, Message) {
-  msg := NewPromptMessage(flags, &format, args...)
-  log.Add(msg)
+func (l *Logger) Prompt(flags PromptFlag, args ...interface{}) (string, Message) {
+  return l.prompt(flags, nil, args...)
+}
 
-  // Do write stuff here.
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+func (l *Logger) Promptf(flags PromptFlag, format string, args ...interface{}) (string, Message) {
+  return l.prompt(flags, &format, args...)
+}
 
-  // Do read stuff here.
-  res := ScanLine()
+func Prompt(flags PromptFlag, args ...interface{}) (string, Message) {
+  return std.Prompt(flags, args...)
+}
 
-  return res, msg
+func Promptf(flags PromptFlag, format string, args ...interface{}) (string, Message) {
+  return std.Promptf(flags, format, args...)
 }
 
 // //
@@ -1,11 +1,13 @@
 package robologger
 
 import (
-  "bufio"
   "fmt"
   "io"
   "os"
+  "reflect"
   "regexp"
+  "strings"
+  "time"
 )
 
 // Stdin, Stdout, and Stderr are files opened by the "os" package.
@@ -19,8 +21,47 @@ var (
 type Message interface {
   fmt.Stringer
   Format() string
+  Fields() Fields
+  Time() time.Time
+  Updated() time.Time
+  formatText(multiline bool) string
   getPrintLength() (n int)
   setPrintLength(n int)
+  setFields(fields Fields)
+  setTime(t time.Time)
+  setUpdated(t time.Time)
+}
+
+// timestamps holds the time a message was created and the time it was last
+// updated. It is embedded in each of the message types.
+type timestamps struct {
+  created time.Time
+  updated time.Time
+}
+
+// newTimestamps returns timestamps for a message created at the time t.
+func newTimestamps(t time.Time) timestamps {
+  return timestamps{t, t}
+}
+
+// Time returns the time the message was created.
+func (ts timestamps) Time() time.Time {
+  return ts.created
+}
+
+// Updated returns the time the message was last updated. For messages which
+// are never updated, this is the time the message was created.
+func (ts timestamps) Updated() time.Time {
+  return ts.updated
+}
+
+func (ts *timestamps) setTime(t time.Time) {
+  ts.created = t
+  ts.updated = t
+}
+
+func (ts *timestamps) setUpdated(t time.Time) {
+  ts.updated = t
 }
 
 // Scanner is the interface that implements the ScanLine method.
@@ -31,9 +72,7 @@ type Scanner interface {
 // ScanLine reads the next line of input from the terminal. It implements a
 // Scan method from the bufio Scanner.
 func ScanLine() string {
-  scanner := bufio.NewScanner(Stdin)
-  scanner.Scan()
-  return scanner.Text()
+  return std.ScanLine()
 }
 
 // Writer is the interface that implements the WriteMessage method.
@@ -60,12 +99,38 @@ func removeNewlines(s string) string {
   return rx.ReplaceAllString(s, "")
 }
 
-// log is the default logger.
-var log = NewHistory()
+// cleanNewlines removes newlines from s. If multiline is true, the newlines are
+// kept instead, and "\r\n" is replaced by "\n".
+func cleanNewlines(s string, multiline bool) string {
+  if multiline {
+    return strings.Replace(s, "\r\n", "\n", -1)
+  }
+  return removeNewlines(s)
+}
 
 // printer flags.
 const (
   PR_NO_COLOR = 1 << iota
+
+  // The time prefix flags add the time of the message in front of each
+  // message. Only one of them should be set.
+  PR_TIME_RFC3339 // 2006-01-02T15:04:05Z07:00
+  PR_TIME_CLOCK   // 15:04:05.000
+  PR_TIME_ELAPSED // 00:01:02.345, the time since the logger was created.
+  PR_TIME_DMESG   // [  62.345], the seconds since the logger was created.
+
+  // PR_TTY treats the output as a terminal, even if it is not detected as
+  // one, e.g. when piping colored output to a pager.
+  PR_TTY
+
+  // PR_WORD_WRAP wraps long messages at word boundaries, and indents the
+  // continuation lines to line up with the text after the level prefix.
+  PR_WORD_WRAP
+
+  // PR_MULTILINE keeps the newlines in messages, e.g. in stack traces, instead
+  // of removing them. The lines after the first are indented to line up with
+  // the text after the level prefix. Progress bars stay on a single line.
+  PR_MULTILINE
 )
 
 // printer is the default printer to the terminal.
@@ -76,6 +141,51 @@ type printer struct {
 
   // out is the io.Writer to use when printing messages.
   out io.Writer
+
+  // term is the terminal used to clear lines before printing.
+  term *Terminal
+
+  // enc is the encoder used to write messages. If enc is nil, messages are
+  // written as formatted text for the terminal.
+  enc Encoder
+
+  // start is the time used for the relative time prefixes.
+  start time.Time
+
+  // level is the level threshold of the printer.
+  level LogFlag
+
+  // ttys caches whether the files the printer writes to are terminals.
+  ttys map[*os.File]bool
+
+  // last holds the last Status and the last Progress message printed to each
+  // output which is not a terminal, with the key they were printed with. Only
+  // the most recent message of each kind is kept, so that finished messages
+  // are not held forever.
+  last map[appendSlot]appended
+}
+
+// appendSlot identifies the most recent message of a kind printed to an output.
+// Outputs which cannot be compared share a slot.
+type appendSlot struct {
+  out interface{}
+  kind string
+}
+
+// appended is a message printed to an output which is not a terminal, and the
+// key it was printed with.
+type appended struct {
+  msg Message
+  key string
+}
+
+// slot returns the slot of the message in the output of the printer.
+func (p printer) slot(msg Message) appendSlot {
+  var out interface{}
+  if p.out != nil && reflect.TypeOf(p.out).Comparable() {
+    out = p.out
+  }
+  return appendSlot{out, messageKind(msg)}
 }
 
 // newPrinter creates a new printer to write to the terminal.
@@ -84,85 +194,272 @@ func newPrinter(out io.Writer, length int, flags int) *printer {
     out: out,
     length: length,
     flags: flags,
+    term: NewTerminal(ESC),
+    start: time.Now(),
+    level: L_DEBUG,
+    ttys: make(map[*os.File]bool),
+    last: make(map[appendSlot]appended),
+  }
+}
+
+// interactive reports whether the output w is a terminal, where messages can
+// be colored and redrawn in place.
+func (p printer) interactive(w io.Writer) bool {
+  if p.flags&PR_TTY != 0 {
+    return true
+  }
+
+  f, ok := w.(*os.File)
+  if !ok {
+    return false
+  }
+
+  tty, ok := p.ttys[f]
+  if !ok {
+    tty = isTerminal(f.Fd())
+    p.ttys[f] = tty
+  }
+
+  return tty
+}
+
+// appendKey returns the text which decides whether an updated message is
+// printed again when the output is not a terminal. Status messages are printed
+// again when their text changes, and Progress messages every 10%.
+func appendKey(msg Message) string {
+  if pm, ok := msg.(*ProgressMessage); ok {
+    step := pm.progress / 10
+    if step > 10 {
+      step = 10
+    }
+    if step < 0 {
+      step = 0
+    }
+    return fmt.Sprint(step)
+  }
+  return msg.Format()
+}
+
+// writeAppend writes the message without wrapping it and without terminal
+// control codes, for output which is not a terminal. Colors are removed unless the
+// ColorMode is CM_ALWAYS.
+func (p printer) writeAppend(msg Message) (n int, err error) {
+  switch msg.(type) {
+  case *StatusMessage, *ProgressMessage:
+    p.last[p.slot(msg)] = appended{msg, appendKey(msg)}
+  }
+
+  lines, _ := p.wrap(msg, 0)
+  s := strings.Join(lines, "\n")
+
+  // Colors are only kept if they are forced.
+  if GetColorMode() != CM_ALWAYS {
+    s = stripANSI(s)
+  }
+
+  _, err = io.WriteString(p.out, s)
+  return len(lines), err
+}
+
+// appendUpdate prints an updated message on a new line, for output which is
+// not a terminal. The message is only printed if it changed enough since it
+// was last printed, or if another message of its kind was printed since.
+func (p printer) appendUpdate(msg Message) {
+  if p.last[p.slot(msg)] == (appended{msg, appendKey(msg)}) {
+    return
+  }
+
+  p.writeAppend(msg)
+  fmt.Fprint(p.out, "\n")
+}
+
+// text returns the text printed for the message, with its prefix.
+func (p printer) text(msg Message) string {
+  s := p.prefix(msg) + msg.formatText(p.flags&PR_MULTILINE != 0)
+
+  // The message is already formatted without colors if the ColorMode is
+  // CM_NEVER, but the printer can also turn them off for its own output.
+  if p.flags&PR_NO_COLOR != 0 {
+    s = stripANSI(s)
+  }
+
+  // Colors the terminal cannot print are replaced by the nearest colors it
+  // can, for every message type and style.
+  return downsample(s, GetColorDepth())
+}
+
+// wrap splits the text printed for the message into lines which fit in w
+// columns, according to the wrapping mode of the printer, and returns the lines
+// and the number of columns each of them takes. The lines of multi-line
+// messages are wrapped separately.
+func (p printer) wrap(msg Message, w int) (lines []string, widths []int) {
+  indent := p.indent(msg, w)
+
+  for i, s := range strings.Split(p.text(msg), "\n") {
+    if i > 0 {
+      s = strings.Repeat(" ", indent) + s
+    }
+
+    // Tabs, e.g. in stack traces, take up to the next tab stop.
+    s = expandTabs(s)
+
+    var ls []string
+    var ws []int
+
+    if p.flags&PR_WORD_WRAP != 0 {
+      ls, ws = wrapWords(s, w, indent)
+    } else {
+      ls, ws = wrap(s, w)
+    }
+
+    lines, widths = append(lines, ls...), append(widths, ws...)
   }
+
+  return
+}
+
+// indent returns the indentation of the continuation lines of the message,
+// which lines them up with the text after the level prefix, when they are
+// wrapped at the width w.
+func (p printer) indent(msg Message, w int) int {
+  indent := StringWidth(p.prefix(msg))
+  if lm, ok := msg.(*LogMessage); ok {
+    indent = indent + StringWidth(lm.prefix())
+  }
+
+  // A long prefix, e.g. with the caller, would leave little room for the
+  // continuation lines.
+  if w > 0 && indent * 2 > w {
+    indent = 0
+  }
+
+  return indent
 }
 
-// pr is the default implementation of the printer.
-var pr = newPrinter(Stdout, 80, 0)
+// reflowedLines returns the number of lines taken by lines which are widths
+// columns wide, after the terminal rewraps each of them at the width w.
+func reflowedLines(widths []int, w int) (n int) {
+  for _, lw := range widths {
+    if w > 0 && lw > w {
+      n = n + (lw + w - 1) / w
+    } else {
+      n = n + 1
+    }
+  }
+  return
+}
+
+// Enabled reports whether messages with the level flags are printed.
+func (p printer) Enabled(flags LogFlag) bool {
+  return atLevel(flags, p.level)
+}
 
-// SetPrinterFlags sets the flags for the printer.
+// SetPrinterFlags sets the flags for the printer of the default logger.
 func SetPrinterFlags(flags int)  {
-  pr.flags = flags
+  std.SetPrinterFlags(flags)
 }
 
 // SetPrintLength sets the maximum message length for the printer.
 func (p *printer) SetPrintLength(l int) {
-  pr.length = l
+  p.length = l
 }
 
-// SetOutput sets the output stream to use for the printer.
+// SetOutput sets the output stream to use for the printer. The control
+// sequences of the terminal are written to the same stream.
 func (p *printer) SetOutput(out io.Writer)  {
   p.out = out
+
+  if p.term != nil {
+    p.term.SetOutput(out)
+  }
+}
+
+// SetEncoder sets the encoder used to write messages. If enc is nil, messages
+// are written as formatted text for the terminal.
+func (p *printer) SetEncoder(enc Encoder) {
+  p.enc = enc
+}
+
+// encode writes the message encoded by the encoder of the printer, followed by
+// a newline.
+func (p printer) encode(msg Message, update bool) (n int, err error) {
+  b, err := p.enc.Encode(msg, update)
+  if err != nil {
+    return 0, err
+  }
+
+  _, err = p.out.Write(append(b, '\n'))
+  return 1, err
+}
+
+// prefix returns the time prefix and the caller of the message, if the caller
+// was recorded.
+func (p printer) prefix(msg Message) (s string) {
+  s = p.timePrefix(msg)
+
+  if lm, ok := msg.(*LogMessage); ok && lm.caller != nil {
+    s = s + Color(C_DARK_GRAY_FG) + lm.caller.String() + Color(C_RESET) + " "
+  }
+
+  return
+}
+
+// timePrefix returns the time prefix of the message, according to the time
+// prefix flags of the printer. The prefix shows the last time the message was
+// updated.
+func (p printer) timePrefix(msg Message) string {
+  t := msg.Updated()
+
+  var s string
+
+  switch {
+  case p.flags&PR_TIME_RFC3339 != 0:
+    s = t.Format(time.RFC3339)
+  case p.flags&PR_TIME_CLOCK != 0:
+    s = t.Format("15:04:05.000")
+  case p.flags&PR_TIME_ELAPSED != 0:
+    d := t.Sub(p.start)
+    if d < 0 {
+      d = 0
+    }
+    h, m := int(d.Hours()), int(d.Minutes()) % 60
+    sec := (d % time.Minute).Seconds()
+    s = fmt.Sprintf("%02d:%02d:%06.3f", h, m, sec)
+  case p.flags&PR_TIME_DMESG != 0:
+    d := t.Sub(p.start)
+    if d < 0 {
+      d = 0
+    }
+    s = fmt.Sprintf("[%8.3f]", d.Seconds())
+  default:
+    return ""
+  }
+
+  return Color(C_DARK_GRAY_FG) + s + Color(C_RESET) + " "
 }
 
 // WriteMessage is the implementation of the WriteMessage method.
 func (p printer) WriteMessage(msg Message) (n int, err error) {
-  // ll holds the line length of the message.
-  var ll = -1
-  var esc = rune(term.ESC)
-  n = 1
-  err = nil
+  // Encoded messages are written on a single line, without any terminal
+  // control codes.
+  if p.enc != nil {
+    return p.encode(msg, false)
+  }
 
-  wr := bufio.NewWriter(p.out)
-  defer wr.Flush()
+  // Output which is not a terminal is append-only, so it is written without
+  // colors, control codes or wrapping.
+  if !p.interactive(p.out) {
+    return p.writeAppend(msg)
+  }
 
-  // Get the rune slice of the message.
-  rmsg := []rune(msg.Format())
+  lines, _ := p.wrap(msg, p.length)
 
   // For cleanliness, we clear the lines before we print. This way, if we are
   // updating a log or if we are overwriting an existing log in the terminal,
   // we will have a clean line to work with.
-  term.Clear()
-
-  for i := 0; i < len(rmsg); i++ {
-    switch rmsg[i] {
-    case esc:
-      // Flush the buffer up to this point.
-      wr.Flush()
-
-      // Write the escape code.
-      wr.WriteRune(rmsg[i])
-
-      // Write the ANSI color code that immediately follows the escape code.
-      for j := i; rmsg[j] != 'm'; j++ {
-        wr.WriteRune(rmsg[j])
-
-        // Cut out the runes.
-        i++
-      }
-
-      // Write the 'm' rune, completing the color code.
-      wr.WriteRune(rmsg[i])
-
-      // If PR_NO_COLOR is specified, we clear the buffer and reset it.
-      if p.flags&PR_NO_COLOR != 0 {
-        wr.Reset(p.out)
-      }
-
-    default:
-      // If the line length equals the maximum length, start a new line.
-      if ll > 0 && (ll + 1) % p.length == 0 {
-        wr.WriteRune('\n')
-        wr.WriteString(fmt.Sprintf("%c[K", term.ESC))
-        n = n + 1;
-      }
-
-      // Write the rune.
-      wr.WriteRune(rmsg[i])
-      // Increment the line length.
-      ll = ll + 1
-    }
-  }
+  p.term.Clear()
 
-  return
+  _, err = io.WriteString(p.out, strings.Join(lines, fmt.Sprintf("\n%c[K", p.term.ESC)))
+
+  return len(lines), err
 }
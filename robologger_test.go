@@ -1,6 +1,14 @@
 package robologger
 
-import "fmt"
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "log"
+  "os"
+  "strings"
+  "time"
+)
 
 func ExampleUnicode() {
   fmt.Print("🤖")
@@ -51,3 +59,233 @@ func ExampleColors() {
 
   fmt.Print("\n")
 }
+
+func ExampleParseLevel() {
+  level, _ := ParseLevel("WARNING")
+  fmt.Println(level, level <= L_INFO)
+  // Output:
+  // warn true
+}
+
+func ExampleFields() {
+  fields := newFields("robot", "r2", "speed", 1.5, "task", "pick up")
+  v, _ := fields.Get("speed")
+  fmt.Println(fields, v)
+  // Output:
+  // robot=r2 speed=1.5 task="pick up" 1.5
+}
+
+func ExampleJSONEncoder() {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{Stdout: &buf, Encoder: NewJSONEncoder()})
+  l.With("robot", "r2").Info("Hello.")
+
+  var v map[string]interface{}
+  json.Unmarshal(buf.Bytes(), &v)
+
+  fmt.Println(v["level"], v["kind"], v["msg"], v["fields"])
+  // Output:
+  // info log Hello. map[robot:r2]
+}
+
+func ExampleLogfmtEncoder() {
+  msg := NewLogMessage(L_WARN, nil, `low "battery"`)
+  msg.setFields(newFields("robot", "r2", "path", `C:\bots`, "cells", 4))
+
+  b, _ := NewLogfmtEncoder().Encode(msg, false)
+
+  // Remove the timestamp.
+  fmt.Println(strings.SplitN(string(b), " ", 2)[1])
+  // Output:
+  // level=warn msg="low \"battery\"" robot=r2 path="C:\\bots" cells=4
+}
+
+func ExampleLoggerOptions_clock() {
+  var buf bytes.Buffer
+
+  clock := func() time.Time {
+    return time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+  }
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &buf,
+    Encoder: NewLogfmtEncoder(),
+    Clock: clock,
+  })
+  l.Info("Started.")
+
+  fmt.Print(buf.String())
+  // Output:
+  // ts=2017-06-01T12:00:00Z level=info msg=Started.
+}
+
+func ExampleLogger_Helper() {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &buf,
+    Stderr: &buf,
+    Encoder: NewJSONEncoder(),
+    Caller: true,
+  })
+
+  warn := func(msg string) {
+    l.Helper()
+    l.Warn(msg)
+  }
+
+  warn("Low battery.")
+
+  var v map[string]interface{}
+  json.Unmarshal(buf.Bytes(), &v)
+
+  fn := v["func"].(string)
+  fmt.Println(strings.Contains(v["caller"].(string), "robologger_test.go:"), fn[strings.LastIndex(fn, ".") + 1:])
+  // Output:
+  // true ExampleLogger_Helper
+}
+
+func ExampleStreamSink() {
+  l := NewLogger(LoggerOptions{
+    Level: L_DEBUG,
+    TerminalLevel: L_PRINT,
+    Sinks: []Sink{NewStreamSink(os.Stdout, nil, L_DEBUG)},
+  })
+
+  l.With("id", 1).Debug("Debugging.")
+  l.Warn("Warning.")
+  // Output:
+  // [DEBUG] Debugging. id=1
+  // [WARN]  Warning.
+}
+
+func ExampleLogger_Panic() {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &buf,
+    Stderr: &buf,
+    Encoder: NewTextEncoder(false),
+  })
+
+  // Errors are logged without panicking.
+  l.Error("Motor stalled.")
+
+  func() {
+    defer func() {
+      fmt.Println("recovered:", recover())
+    }()
+    l.Panic("Arm crashed.")
+  }()
+
+  fmt.Println(l.History().Count(L_ERROR | L_PANIC))
+  l.Summary()
+
+  fmt.Print(buf.String())
+  // Output:
+  // recovered: Arm crashed.
+  // 2
+  // [ERROR] Motor stalled.
+  // [PANIC] Arm crashed.
+  //         2 errors, 0 warnings
+}
+
+func ExampleLogger_SetRoute() {
+  var stdout, stderr bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &stdout,
+    Stderr: &stderr,
+    Encoder: NewTextEncoder(false),
+  })
+
+  l.Error("Motor stalled.")
+  l.Info("Retrying.")
+
+  l.SetRoute(L_ERROR | L_WARN, &stdout)
+  l.Warn("Motor slow.")
+
+  fmt.Print("stdout:\n", stdout.String(), "stderr:\n", stderr.String())
+  // Output:
+  // stdout:
+  // [INFO]  Retrying.
+  // [WARN]  Motor slow.
+  // stderr:
+  // [ERROR] Motor stalled.
+}
+
+func ExampleRedirectStdLog() {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &buf,
+    Stderr: &buf,
+    Encoder: NewTextEncoder(false),
+  })
+
+  restore := RedirectStdLog(l)
+  defer restore()
+
+  log.Printf("ERROR: cannot open %s", "arm.cfg")
+  log.Print("[warn] retrying")
+  log.Print("information only")
+
+  fmt.Print(buf.String())
+  // Output:
+  // [ERROR] cannot open arm.cfg
+  // [WARN]  retrying
+  // [INFO]  information only
+}
+
+func ExampleIsTerminal() {
+  var buf bytes.Buffer
+  fmt.Println(IsTerminal(&buf))
+  // Output:
+  // false
+}
+
+func ExampleLogger_Progress_nonTerminal() {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{Stdout: &buf})
+
+  // The output is not a terminal, so the progress bar is printed again on a
+  // new line every 10%.
+  update := l.Progress("Homing")
+  for i := 0; i <= 25; i += 5 {
+    update(i, "Homing")
+  }
+
+  fmt.Print(buf.String())
+  // Output:
+  //     0%  [>                                       ] Homing
+  //    10%  [====>                                   ] Homing
+  //    20%  [========>                               ] Homing
+}
+
+func ExampleSetColorMode() {
+  mode := GetColorMode()
+  defer SetColorMode(mode)
+
+  SetColorMode(CM_NEVER)
+
+  msg := NewLogMessage(L_WARN, nil, "Battery low.")
+  fmt.Printf("%q\n", msg.Format())
+  // Output:
+  // "[WARN]  Battery low."
+}
+
+func ExampleStyle() {
+  mode := GetColorMode()
+  defer SetColorMode(mode)
+  SetColorMode(CM_ALWAYS)
+
+  warn := Style{}.Foreground(Hex("#ff8800")).Bold()
+
+  fmt.Printf("%q\n", warn.Render("Battery low."))
+  fmt.Printf("%q\n", warn.Dim().Transition(warn))
+  // Output:
+  // "\x1b[1;38;2;255;136;0mBattery low.\x1b[0m"
+  // "\x1b[2m"
+}
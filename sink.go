@@ -0,0 +1,167 @@
+package robologger
+
+import (
+  "io"
+  "sync"
+)
+
+// Sink is the interface that implements methods for writing messages to an
+// output destination, such as a file or a socket. A logger writes every message
+// to the terminal and to each of its sinks.
+//
+// Enabled reports whether messages with the level flags are written to the
+// sink. Emit writes the message msg to the sink. If update is true, msg has
+// already been written once and is being written again because it changed.
+//
+// Only the terminal redraws updated messages in place. Sinks receive updates
+// as new messages, e.g. a new line in a file.
+type Sink interface {
+  Enabled(flags LogFlag) bool
+  Emit(msg Message, update bool) error
+}
+
+// Syncer is the interface that implements the Sync method, which flushes any
+// buffered data of a sink to its destination.
+type Syncer interface {
+  Sync() error
+}
+
+// StreamSink is a Sink which writes encoded messages to an io.Writer, one
+// message per line.
+type StreamSink struct {
+  mu sync.Mutex
+
+  w io.Writer
+  enc Encoder
+  level LogFlag
+}
+
+// NewStreamSink returns a new StreamSink which writes messages up to the level
+// threshold level to w, encoded by the encoder enc. If enc is nil, messages
+// are written as plain text without colors. Use NewTextEncoder(true) for
+// colored text. If level is zero, it defaults to L_DEBUG.
+func NewStreamSink(w io.Writer, enc Encoder, level LogFlag) *StreamSink {
+  if enc == nil {
+    enc = NewTextEncoder(false)
+  }
+  if level == 0 {
+    level = L_DEBUG
+  }
+
+  return &StreamSink{
+    w: w,
+    enc: enc,
+    level: level,
+  }
+}
+
+// Enabled is the implementation of the Sink interface.
+func (s *StreamSink) Enabled(flags LogFlag) bool {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  return atLevel(flags, s.level)
+}
+
+// SetLevel sets the level threshold of the sink.
+func (s *StreamSink) SetLevel(level LogFlag) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.level = level
+}
+
+// Emit is the implementation of the Sink interface.
+func (s *StreamSink) Emit(msg Message, update bool) error {
+  b, err := s.enc.Encode(msg, update)
+  if err != nil {
+    return err
+  }
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  _, err = s.w.Write(append(b, '\n'))
+  return err
+}
+
+// Sync flushes the underlying writer if it implements the Syncer interface,
+// e.g. an *os.File.
+func (s *StreamSink) Sync() error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  if syncer, ok := s.w.(Syncer); ok {
+    return syncer.Sync()
+  }
+  return nil
+}
+
+// AddSink adds the sink s to the logger. Every message printed by the logger,
+// and by the loggers derived from it, is also written to s.
+func (l *Logger) AddSink(s Sink) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.sinks = append(l.sinks, s)
+}
+
+// Sync flushes the buffered data of the sinks of the logger. It returns the
+// first error encountered, if any.
+func (l *Logger) Sync() (err error) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+
+  for _, s := range l.sinks {
+    if syncer, ok := s.(Syncer); ok {
+      if serr := syncer.Sync(); serr != nil && err == nil {
+        err = serr
+      }
+    }
+  }
+
+  return
+}
+
+// Close flushes and closes the sinks of the logger which implement io.Closer,
+// and removes all sinks from the logger. The logger stops following the size of
+// the terminal. It returns the first error encountered, if any.
+func (l *Logger) Close() (err error) {
+  err = l.Sync()
+
+  l.mu.Lock()
+  defer l.mu.Unlock()
+
+  for _, s := range l.sinks {
+    if closer, ok := s.(io.Closer); ok {
+      if cerr := closer.Close(); cerr != nil && err == nil {
+        err = cerr
+      }
+    }
+  }
+
+  l.sinks = nil
+
+  l.stopResize()
+
+  return
+}
+
+// emit writes the message to each of the sinks of the logger which are enabled
+// for the level of the message.
+func (l *Logger) emit(msg Message, update bool) {
+  level := messageLevel(msg)
+
+  for _, s := range l.sinks {
+    if s.Enabled(level) {
+      s.Emit(msg, update)
+    }
+  }
+}
+
+// AddSink adds the sink s to the default logger.
+func AddSink(s Sink) {
+  std.AddSink(s)
+}
+
+// Sync flushes the buffered data of the sinks of the default logger.
+func Sync() error {
+  return std.Sync()
+}
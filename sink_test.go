@@ -0,0 +1,328 @@
+//go:build !windows
+// +build !windows
+
+package robologger
+
+import (
+  "compress/gzip"
+  "fmt"
+  "io/ioutil"
+  "net"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+  "time"
+)
+
+func TestFileSinkRotate(t *testing.T) {
+  dir, err := ioutil.TempDir("", "robologger")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+
+  now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+  clock := func() time.Time {
+    now = now.Add(time.Second)
+    return now
+  }
+
+  name := filepath.Join(dir, "robot.log")
+
+  fs, err := NewFileSink(FileSinkOptions{
+    Filename: name,
+    Encoder: NewTextEncoder(true),
+    MaxSize: 64,
+    MaxBackups: 2,
+    Compress: true,
+    Clock: clock,
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  for i := 0; i < 10; i++ {
+    fs.Emit(NewLogMessage(L_WARN, nil, "The battery is running low."), false)
+  }
+
+  if err := fs.Close(); err != nil {
+    t.Fatal(err)
+  }
+
+  b, err := ioutil.ReadFile(name)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if s := string(b); s != "[WARN]  The battery is running low.\n" {
+    t.Errorf("unexpected file contents %q", s)
+  }
+
+  backups := fs.backups()
+  if len(backups) != 2 {
+    t.Fatalf("expected 2 rotated files, got %d", len(backups))
+  }
+
+  for _, b := range backups {
+    if !strings.HasSuffix(b.name, ".log.gz") {
+      t.Errorf("rotated file %s is not compressed", b.name)
+      continue
+    }
+
+    f, err := os.Open(b.name)
+    if err != nil {
+      t.Fatal(err)
+    }
+    zr, err := gzip.NewReader(f)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if _, err := ioutil.ReadAll(zr); err != nil {
+      t.Errorf("cannot decompress %s: %v", b.name, err)
+    }
+    f.Close()
+  }
+
+  if err := fs.Emit(NewLogMessage(L_WARN, nil, "Closed."), false); err != os.ErrClosed {
+    t.Errorf("expected os.ErrClosed after Close, got %v", err)
+  }
+}
+
+func TestFileSinkBackupsPattern(t *testing.T) {
+  dir, err := ioutil.TempDir("", "robologger")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+
+  now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+  clock := func() time.Time {
+    now = now.Add(time.Second)
+    return now
+  }
+
+  // The name is not a glob pattern, and another log with a matching name is
+  // not pruned.
+  other := filepath.Join(dir, "robot1-2017-06-01T12-00-00.000.log")
+  ioutil.WriteFile(other, nil, 0644)
+
+  fs, err := NewFileSink(FileSinkOptions{
+    Filename: filepath.Join(dir, "robot[1].log"),
+    MaxBackups: 1,
+    Clock: clock,
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  for i := 0; i < 3; i++ {
+    fs.Rotate()
+  }
+  if err := fs.Close(); err != nil {
+    t.Fatal(err)
+  }
+
+  if backups := fs.backups(); len(backups) != 1 {
+    t.Errorf("expected 1 rotated file, got %d", len(backups))
+  }
+  if !fileExists(other) {
+    t.Error("a log with a matching name was pruned")
+  }
+}
+
+func TestFileSinkReopen(t *testing.T) {
+  dir, err := ioutil.TempDir("", "robologger")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+
+  name := filepath.Join(dir, "robot.log")
+
+  fs, err := NewFileSink(FileSinkOptions{Filename: name, Encoder: NewTextEncoder(true)})
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  fs.Emit(NewLogMessage(L_WARN, nil, "Before."), false)
+
+  // The file is closed, as when it cannot be opened again after a rotation.
+  fs.mu.Lock()
+  fs.close()
+  fs.mu.Unlock()
+
+  if err := fs.Emit(NewLogMessage(L_WARN, nil, "After."), false); err != nil {
+    t.Fatal(err)
+  }
+  if err := fs.Close(); err != nil {
+    t.Fatal(err)
+  }
+
+  b, err := ioutil.ReadFile(name)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if s := string(b); s != "[WARN]  Before.\n[WARN]  After.\n" {
+    t.Errorf("unexpected file contents %q", s)
+  }
+}
+
+func TestFileSinkCompressError(t *testing.T) {
+  dir, err := ioutil.TempDir("", "robologger")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+
+  clock := func() time.Time {
+    return time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+  }
+
+  fs, err := NewFileSink(FileSinkOptions{
+    Filename: filepath.Join(dir, "robot.log"),
+    Compress: true,
+    Clock: clock,
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  // The rotated file cannot be compressed, since a directory is in the way.
+  fs.mill.Lock()
+  if err := fs.Rotate(); err != nil {
+    t.Fatal(err)
+  }
+  os.Mkdir(filepath.Join(dir, "robot-2017-06-01T12-00-00.000.log.gz"), 0755)
+  fs.mill.Unlock()
+
+  if err := fs.Close(); err == nil {
+    t.Error("expected the compression error from Close")
+  }
+}
+
+func TestFileSinkMaxAge(t *testing.T) {
+  dir, err := ioutil.TempDir("", "robologger")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+
+  // The clock is west of UTC, so that a timestamp read back in the wrong time
+  // zone makes a backup look hours older than it is.
+  now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.FixedZone("EST", -5 * 60 * 60))
+  clock := func() time.Time {
+    return now
+  }
+
+  fs, err := NewFileSink(FileSinkOptions{
+    Filename: filepath.Join(dir, "robot.log"),
+    MaxAge: time.Hour,
+    Clock: clock,
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer fs.Close()
+
+  rotate := func() {
+    if err := fs.Rotate(); err != nil {
+      t.Fatal(err)
+    }
+    fs.wg.Wait()
+  }
+
+  rotate()
+
+  backups := fs.backups()
+  if len(backups) != 1 {
+    t.Fatalf("expected the new rotated file to be kept, got %d rotated files", len(backups))
+  }
+  if !backups[0].t.Equal(now) {
+    t.Errorf("rotated file has time %v, want %v", backups[0].t, now)
+  }
+
+  now = now.Add(2 * time.Hour)
+  rotate()
+
+  backups = fs.backups()
+  if len(backups) != 1 || !backups[0].t.Equal(now) {
+    t.Errorf("expected only the rotated file newer than MaxAge, got %v", backups)
+  }
+}
+
+func TestSyslogSink(t *testing.T) {
+  dir, err := ioutil.TempDir("", "robologger")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+
+  path := filepath.Join(dir, "log")
+
+  listen := func() *net.UnixConn {
+    conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+    if err != nil {
+      t.Fatal(err)
+    }
+    conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+    return conn
+  }
+
+  read := func(conn *net.UnixConn) string {
+    b := make([]byte, 2048)
+    n, err := conn.Read(b)
+    if err != nil {
+      t.Fatal(err)
+    }
+    return string(b[:n])
+  }
+
+  conn := listen()
+
+  s, err := NewSyslogSink(SyslogSinkOptions{
+    Network: "unixgram",
+    Address: path,
+    Hostname: "robot",
+    AppName: "arm",
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer s.Close()
+
+  msg := NewLogMessage(L_WARN, nil, "The battery is low.")
+  msg.setFields(newFields("cell", 2, "note", `a "quoted" [value]`))
+  msg.setTime(time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC))
+
+  if err := s.Emit(msg, false); err != nil {
+    t.Fatal(err)
+  }
+
+  want := fmt.Sprintf(`<12>1 2017-06-01T12:00:00.000000Z robot arm %d log [fields@32473 cell="2" note="a \"quoted\" [value\]"] The battery is low.`, os.Getpid())
+  if got := read(conn); got != want {
+    t.Errorf("unexpected syslog message\n got: %s\nwant: %s", got, want)
+  }
+
+  // The sink reconnects after the daemon restarts.
+  conn.Close()
+  os.Remove(path)
+  conn = listen()
+  defer conn.Close()
+
+  // The message has no time, so the timestamp is the nil value.
+  msg = NewLogMessage(L_ERROR, nil, "Reconnected.")
+  msg.setTime(time.Time{})
+
+  s.Emit(NewLogMessage(L_INFO, nil, "Lost."), false)
+  if err := s.Emit(msg, false); err != nil {
+    t.Fatal(err)
+  }
+
+  got := read(conn)
+  for !strings.HasSuffix(got, "Reconnected.") && strings.HasSuffix(got, "Lost.") {
+    got = read(conn)
+  }
+  if !strings.HasPrefix(got, "<11>1 - ") || !strings.HasSuffix(got, " log - Reconnected.") {
+    t.Errorf("unexpected syslog message after reconnecting: %s", got)
+  }
+}
@@ -0,0 +1,130 @@
+//go:build go1.21
+// +build go1.21
+
+package robologger
+
+import (
+  "context"
+  "log/slog"
+  "runtime"
+)
+
+// SlogHandler is a slog.Handler which prints slog records using a Logger, so
+// that they are rendered like any other message and added to its History.
+//
+// The level of a record is mapped to the nearest LogFlag at or below it, e.g.
+// slog.LevelWarn to L_WARN. The attributes of a record become structured
+// fields, and the names of groups are added in front of the keys of their
+// attributes, e.g. "request.id".
+type SlogHandler struct {
+  l *Logger
+
+  // fields are the fields added using WithAttrs, and prefix is the prefix
+  // added to the keys of attributes by the groups opened using WithGroup.
+  fields Fields
+  prefix string
+}
+
+// NewSlogHandler returns a new SlogHandler which prints records using the
+// logger l. If l is nil, the default logger is used.
+func NewSlogHandler(l *Logger) *SlogHandler {
+  if l == nil {
+    l = std
+  }
+  return &SlogHandler{l: l}
+}
+
+// slogLevel returns the LogFlag corresponding to the slog level.
+func slogLevel(level slog.Level) LogFlag {
+  switch {
+  case level < slog.LevelInfo:
+    return L_DEBUG
+  case level < slog.LevelWarn:
+    return L_INFO
+  case level < slog.LevelError:
+    return L_WARN
+  default:
+    return L_ERROR
+  }
+}
+
+// Enabled is the implementation of the slog.Handler interface.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+  return h.l.Enabled(slogLevel(level))
+}
+
+// Handle is the implementation of the slog.Handler interface.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+  flags := slogLevel(r.Level)
+
+  msg := NewLogMessage(flags, nil, r.Message)
+
+  fields := make(Fields, len(h.fields), len(h.fields) + r.NumAttrs())
+  copy(fields, h.fields)
+
+  r.Attrs(func(a slog.Attr) bool {
+    fields = appendSlogAttr(fields, h.prefix, a)
+    return true
+  })
+
+  msg.setFields(fields)
+
+  if r.PC != 0 && h.l.captureCallers() {
+    frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+    msg.caller = &Caller{frame.File, frame.Line, frame.Function}
+  }
+
+  h.l.outputAt(msg, r.Time, true)
+
+  return nil
+}
+
+// WithAttrs is the implementation of the slog.Handler interface.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+  nh := *h
+  nh.fields = make(Fields, len(h.fields), len(h.fields) + len(attrs))
+  copy(nh.fields, h.fields)
+
+  for _, a := range attrs {
+    nh.fields = appendSlogAttr(nh.fields, h.prefix, a)
+  }
+
+  return &nh
+}
+
+// WithGroup is the implementation of the slog.Handler interface.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+  if name == "" {
+    return h
+  }
+
+  nh := *h
+  nh.prefix = h.prefix + name + "."
+
+  return &nh
+}
+
+// appendSlogAttr appends the attribute a to fields, with prefix added to its
+// key. Groups are flattened, and empty attributes and groups are ignored.
+func appendSlogAttr(fields Fields, prefix string, a slog.Attr) Fields {
+  a.Value = a.Value.Resolve()
+
+  if a.Equal(slog.Attr{}) {
+    return fields
+  }
+
+  if a.Value.Kind() == slog.KindGroup {
+    // Groups without a key are inlined.
+    if a.Key != "" {
+      prefix = prefix + a.Key + "."
+    }
+
+    for _, ga := range a.Value.Group() {
+      fields = appendSlogAttr(fields, prefix, ga)
+    }
+
+    return fields
+  }
+
+  return append(fields, Field{prefix + a.Key, a.Value.Any()})
+}
@@ -0,0 +1,67 @@
+//go:build go1.21
+// +build go1.21
+
+package robologger
+
+import (
+  "bytes"
+  "encoding/json"
+  "strings"
+  "testing"
+  "testing/slogtest"
+)
+
+func TestSlogHandler(t *testing.T) {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &buf,
+    Stderr: &buf,
+    Level: L_DEBUG,
+    Encoder: NewJSONEncoder(),
+  })
+
+  // results converts the JSON lines into the maps expected by slogtest, with
+  // the dotted keys of the fields nested into groups.
+  results := func() []map[string]interface{} {
+    var ms []map[string]interface{}
+
+    for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+      var v map[string]interface{}
+      if err := json.Unmarshal(line, &v); err != nil {
+        t.Fatal(err)
+      }
+
+      m := make(map[string]interface{})
+      for k, value := range v {
+        if k != "fields" && k != "kind" {
+          m[k] = value
+        }
+      }
+
+      fields, _ := v["fields"].(map[string]interface{})
+      for k, value := range fields {
+        keys := strings.Split(k, ".")
+
+        group := m
+        for _, key := range keys[:len(keys) - 1] {
+          g, ok := group[key].(map[string]interface{})
+          if !ok {
+            g = make(map[string]interface{})
+            group[key] = g
+          }
+          group = g
+        }
+        group[keys[len(keys) - 1]] = value
+      }
+
+      ms = append(ms, m)
+    }
+
+    return ms
+  }
+
+  if err := slogtest.TestHandler(NewSlogHandler(l), results); err != nil {
+    t.Error(err)
+  }
+}
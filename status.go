@@ -1,12 +1,21 @@
 package robologger
 
-import "fmt"
+import (
+  "fmt"
+  "time"
+)
 
 // StatusMessage implements the Message interface.
 type StatusMessage struct {
   // printLength refers to how many lines it takes up on the screen.
   printLength int
 
+  // fields are the structured fields attached to the message.
+  fields Fields
+
+  // timestamps hold the creation and update times of the message.
+  timestamps
+
   symbol rune
 
   format *string
@@ -16,6 +25,7 @@ type StatusMessage struct {
 // NewStatusMessage returns a new Message.
 func NewStatusMessage(format *string, a ...interface{}) *StatusMessage {
   return &StatusMessage{
+    timestamps: newTimestamps(time.Now()),
     format: format,
     a: a,
   }
@@ -39,11 +49,25 @@ func (sm *StatusMessage) setPrintLength(n int) {
   sm.printLength = n
 }
 
+// Fields returns the structured fields attached to the message.
+func (sm StatusMessage) Fields() Fields {
+  return sm.fields
+}
+
+func (sm *StatusMessage) setFields(fields Fields) {
+  sm.fields = fields
+}
+
 func (sm StatusMessage) Format() (fmsg string) {
+  return sm.formatText(false)
+}
+
+func (sm StatusMessage) formatText(multiline bool) (fmsg string) {
   s := sm.String()
 
-  // Remove newlines from the args and from the format string.
-  s = removeNewlines(s)
+  // Remove newlines from the args and from the format string, unless they are
+  // kept for multi-line output.
+  s = cleanNewlines(s, multiline)
 
   // s = fmt.Sprintf("%c[90m%s", term.ESC, s) + Color(C_RESET)
   // if len(s) > 80 {
@@ -52,47 +76,45 @@ func (sm StatusMessage) Format() (fmsg string) {
   //   fmsg = s
   // }
 
-  return s
+  return s + sm.fields.Format()
 }
 
-func Status(args ...interface{}) (func(...interface{})) {
-  msg := NewStatusMessage(nil, args...)
-  log.Add(msg)
+// status prints a status message and returns a closure which updates it. Status
+// messages have the L_INFO level, so below that level threshold nothing is
+// printed, and the closure does nothing.
+func (l *Logger) status(format *string, args ...interface{}) (func(...interface{})) {
+  if !l.Enabled(L_INFO) {
+    return func(...interface{}) {}
+  }
 
-  // Do write stuff here.
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
+  msg := NewStatusMessage(format, args...)
+  l.output(msg, true)
 
   // This function is returned to the user as a callable closure which will
   // update the status bar.
   Update := func (args ...interface{}) {
-    msg.a = args
-
-    // Update the message in the log.
-    log.Update(msg)
+    // Update the message in the log. The message is changed while the logger
+    // is locked, since a resize of the terminal can redraw it at any time.
+    l.update(msg, func() {
+      msg.a = args
+    })
   }
 
   return Update
 }
 
-func Statusf(format string, args ...interface{}) (func(...interface{})) {
-  msg := NewStatusMessage(&format, args...)
-  log.Add(msg)
-
-  // Do write stuff here.
-  n, _ := pr.WriteMessage(msg)
-  msg.printLength = n
-
-  fmt.Print("\n")
+func (l *Logger) Status(args ...interface{}) (func(...interface{})) {
+  return l.status(nil, args...)
+}
 
-  // This function is returned to the user as a callable closure which will
-  // update the status bar.
-  Update := func (args ...interface{}) {
-    msg.a = args
+func (l *Logger) Statusf(format string, args ...interface{}) (func(...interface{})) {
+  return l.status(&format, args...)
+}
 
-    // Update the message in the log.
-    log.Update(msg)
-  }
+func Status(args ...interface{}) (func(...interface{})) {
+  return std.Status(args...)
+}
 
-  return Update
+func Statusf(format string, args ...interface{}) (func(...interface{})) {
+  return std.Statusf(format, args...)
 }
@@ -0,0 +1,382 @@
+package robologger
+
+import (
+  "errors"
+  "strconv"
+  "strings"
+)
+
+// ColorValue is a color in the 16 color, 256 color or 24-bit RGB color space of
+// the terminal. The zero ColorValue is no color, which leaves the color of the
+// terminal unchanged.
+type ColorValue uint32
+
+// The color spaces, stored in the top byte of a ColorValue.
+const (
+  colorNone = iota << 24
+  color16
+  color256
+  colorRGB
+
+  colorSpace = 0xFF << 24
+)
+
+// ANSI16 returns the color n of the 16 color palette: 0 to 7 are the normal
+// colors (black, red, green, yellow, blue, magenta, cyan and gray), and 8 to 15
+// are their bright versions.
+func ANSI16(n int) ColorValue {
+  return ColorValue(color16 | uint32(n & 0xF))
+}
+
+// ANSI256 returns the color n of the 256 color palette: 0 to 15 are the 16
+// color palette, 16 to 231 are a 6x6x6 color cube and 232 to 255 are a
+// grayscale ramp.
+func ANSI256(n int) ColorValue {
+  return ColorValue(color256 | uint32(n & 0xFF))
+}
+
+// RGB returns the 24-bit color with the red, green and blue components r, g
+// and b.
+func RGB(r, g, b uint8) ColorValue {
+  return ColorValue(colorRGB | uint32(r) << 16 | uint32(g) << 8 | uint32(b))
+}
+
+// ParseHex parses a hex color, e.g. "#ff8800" or "f80".
+func ParseHex(s string) (ColorValue, error) {
+  h := strings.TrimPrefix(s, "#")
+
+  if len(h) == 3 {
+    h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+  }
+
+  v, err := strconv.ParseUint(h, 16, 32)
+  if err != nil || len(h) != 6 {
+    return 0, errors.New("robologger: invalid hex color " + strconv.Quote(s))
+  }
+
+  return ColorValue(colorRGB | uint32(v)), nil
+}
+
+// Hex returns the hex color s, e.g. "#ff8800". If s is not a valid hex color,
+// Hex returns no color.
+func Hex(s string) ColorValue {
+  c, _ := ParseHex(s)
+  return c
+}
+
+// IsSet reports whether c is a color, and not the zero ColorValue.
+func (c ColorValue) IsSet() bool {
+  return c&colorSpace != colorNone
+}
+
+// index returns the palette index or the RGB value of the color.
+func (c ColorValue) index() int {
+  return int(c &^ colorSpace)
+}
+
+// paletteRGB returns the RGB value of the entry n of the 256 color palette. The
+// values of the 16 color palette are those of xterm.
+func paletteRGB(n int) (r, g, b uint8) {
+  switch {
+  case n < 16:
+    v := xtermColors[n]
+    return uint8(v >> 16), uint8(v >> 8), uint8(v)
+  case n < 232:
+    n = n - 16
+    return cubeLevels[n / 36], cubeLevels[n / 6 % 6], cubeLevels[n % 6]
+  default:
+    v := uint8(8 + (n - 232) * 10)
+    return v, v, v
+  }
+}
+
+// xtermColors are the RGB values of the 16 color palette in xterm.
+var xtermColors = [16]uint32{
+  0x000000, 0xcd0000, 0x00cd00, 0xcdcd00, 0x0000ee, 0xcd00cd, 0x00cdcd, 0xe5e5e5,
+  0x7f7f7f, 0xff0000, 0x00ff00, 0xffff00, 0x5c5cff, 0xff00ff, 0x00ffff, 0xffffff,
+}
+
+// cubeLevels are the component values of the 6x6x6 color cube of the 256 color
+// palette.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// RGB returns the red, green and blue components of the color. The colors of
+// the palettes are converted using the xterm palette. No color is black.
+func (c ColorValue) RGB() (r, g, b uint8) {
+  switch c & colorSpace {
+  case color16, color256:
+    return paletteRGB(c.index())
+  case colorRGB:
+    v := c.index()
+    return uint8(v >> 16), uint8(v >> 8), uint8(v)
+  }
+  return 0, 0, 0
+}
+
+// params returns the SGR parameters which set the color, using base 30 for the
+// foreground and 40 for the background. RGB colors which are exactly in the 256
+// color cube or grayscale ramp use the shorter 256 color parameters.
+func (c ColorValue) params(base int) string {
+  n := c.index()
+
+  switch c & colorSpace {
+  case color256:
+    if n >= 16 {
+      return strconv.Itoa(base + 8) + ";5;" + strconv.Itoa(n)
+    }
+    fallthrough
+  case color16:
+    if n < 8 {
+      return strconv.Itoa(base + n)
+    }
+    return strconv.Itoa(base + 60 + n - 8)
+  case colorRGB:
+    r, g, b := c.RGB()
+    if i, ok := exactPalette(r, g, b); ok {
+      return strconv.Itoa(base + 8) + ";5;" + strconv.Itoa(i)
+    }
+    return strconv.Itoa(base + 8) + ";2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b))
+  }
+
+  return ""
+}
+
+// exactPalette returns the entry of the 256 color cube or grayscale ramp with
+// exactly the RGB value r, g, b, if there is one.
+func exactPalette(r, g, b uint8) (int, bool) {
+  level := func(v uint8) int {
+    for i, l := range cubeLevels {
+      if l == v {
+        return i
+      }
+    }
+    return -1
+  }
+
+  if lr, lg, lb := level(r), level(g), level(b); lr >= 0 && lg >= 0 && lb >= 0 {
+    return 16 + lr * 36 + lg * 6 + lb, true
+  }
+
+  if r == g && g == b && r >= 8 && r <= 238 && (r - 8) % 10 == 0 {
+    return 232 + int(r - 8) / 10, true
+  }
+
+  return 0, false
+}
+
+// Attr is a text attribute flag.
+type Attr uint8
+
+// Text attributes.
+const (
+  A_BOLD Attr = 1 << iota
+  A_DIM
+  A_ITALIC
+  A_UNDERLINE
+  A_BLINK
+  A_STRIKETHROUGH
+)
+
+// attrCodes are the SGR parameters which turn each attribute on and off. Bold
+// and dim are turned off together.
+var attrCodes = []struct {
+  attr Attr
+  on string
+  off string
+}{
+  {A_BOLD, "1", "22"},
+  {A_DIM, "2", "22"},
+  {A_ITALIC, "3", "23"},
+  {A_UNDERLINE, "4", "24"},
+  {A_BLINK, "5", "25"},
+  {A_STRIKETHROUGH, "9", "29"},
+}
+
+// Style is the style of a piece of text: its foreground and background colors
+// and its attributes. Styles are values, composed by the methods which return a
+// modified copy, e.g.
+//
+//     warn := Style{}.Foreground(Hex("#ff8800")).Bold()
+//
+// The zero Style leaves the text unchanged.
+type Style struct {
+  fg ColorValue
+  bg ColorValue
+  attrs Attr
+}
+
+// NewStyle returns a new Style with the foreground color fg, the background
+// color bg and the attributes attrs.
+func NewStyle(fg ColorValue, bg ColorValue, attrs Attr) Style {
+  return Style{fg, bg, attrs}
+}
+
+// Foreground returns a copy of the style with the foreground color c.
+func (s Style) Foreground(c ColorValue) Style {
+  s.fg = c
+  return s
+}
+
+// Background returns a copy of the style with the background color c.
+func (s Style) Background(c ColorValue) Style {
+  s.bg = c
+  return s
+}
+
+// Attrs returns a copy of the style with the attributes attrs added.
+func (s Style) Attrs(attrs Attr) Style {
+  s.attrs |= attrs
+  return s
+}
+
+// Bold returns a copy of the style in bold.
+func (s Style) Bold() Style {
+  return s.Attrs(A_BOLD)
+}
+
+// Dim returns a copy of the style dimmed.
+func (s Style) Dim() Style {
+  return s.Attrs(A_DIM)
+}
+
+// Italic returns a copy of the style in italics.
+func (s Style) Italic() Style {
+  return s.Attrs(A_ITALIC)
+}
+
+// Underline returns a copy of the style underlined.
+func (s Style) Underline() Style {
+  return s.Attrs(A_UNDERLINE)
+}
+
+// Blink returns a copy of the style blinking.
+func (s Style) Blink() Style {
+  return s.Attrs(A_BLINK)
+}
+
+// Strikethrough returns a copy of the style struck through.
+func (s Style) Strikethrough() Style {
+  return s.Attrs(A_STRIKETHROUGH)
+}
+
+// Inherit returns a copy of the style on top of the style parent: the colors
+// which are not set in s are taken from parent, and the attributes of both are
+// combined.
+func (s Style) Inherit(parent Style) Style {
+  if !s.fg.IsSet() {
+    s.fg = parent.fg
+  }
+  if !s.bg.IsSet() {
+    s.bg = parent.bg
+  }
+  s.attrs |= parent.attrs
+  return s
+}
+
+// params returns the SGR parameters which set the style, from the default
+// style.
+func (s Style) params() []string {
+  var params []string
+
+  for _, ac := range attrCodes {
+    if s.attrs&ac.attr != 0 {
+      params = append(params, ac.on)
+    }
+  }
+
+  if s.fg.IsSet() {
+    params = append(params, s.fg.params(30))
+  }
+  if s.bg.IsSet() {
+    params = append(params, s.bg.params(40))
+  }
+
+  return params
+}
+
+// sgr returns the SGR escape sequence with the parameters params.
+func sgr(params []string) string {
+  if len(params) == 0 {
+    return ""
+  }
+  return string(rune(ESC)) + "[" + strings.Join(params, ";") + "m"
+}
+
+// String returns the escape sequence which sets the style, from the default
+// style. If the ColorMode is CM_NEVER, it returns an empty string.
+func (s Style) String() string {
+  if GetColorMode() == CM_NEVER {
+    return ""
+  }
+  return sgr(s.params())
+}
+
+// Transition returns the shortest escape sequence which changes the style of
+// the terminal from the style from to s: either the changes to each of the
+// colors and attributes, or a reset followed by the style. If the ColorMode is
+// CM_NEVER, it returns an empty string.
+func (s Style) Transition(from Style) string {
+  if GetColorMode() == CM_NEVER || s == from {
+    return ""
+  }
+
+  var params []string
+
+  // Bold and dim are turned off together, so the one which stays on is turned
+  // on again.
+  off := from.attrs &^ s.attrs
+  on := s.attrs &^ from.attrs
+
+  if off&(A_BOLD | A_DIM) != 0 {
+    on |= s.attrs & (A_BOLD | A_DIM)
+  }
+
+  for _, ac := range attrCodes {
+    if off&ac.attr != 0 && (ac.attr != A_DIM || off&A_BOLD == 0) {
+      params = append(params, ac.off)
+    }
+  }
+  for _, ac := range attrCodes {
+    if on&ac.attr != 0 {
+      params = append(params, ac.on)
+    }
+  }
+
+  switch {
+  case s.fg == from.fg:
+  case s.fg.IsSet():
+    params = append(params, s.fg.params(30))
+  default:
+    params = append(params, "39")
+  }
+
+  switch {
+  case s.bg == from.bg:
+  case s.bg.IsSet():
+    params = append(params, s.bg.params(40))
+  default:
+    params = append(params, "49")
+  }
+
+  // A reset followed by the whole style can be shorter.
+  reset := append([]string{"0"}, s.params()...)
+  if s == (Style{}) {
+    reset = []string{""}
+  }
+
+  if len(strings.Join(reset, ";")) < len(strings.Join(params, ";")) {
+    params = reset
+  }
+
+  return sgr(params)
+}
+
+// Render returns the text s in the style, followed by a reset. If the ColorMode
+// is CM_NEVER, or if the style is the zero Style, s is returned unchanged.
+func (s Style) Render(text string) string {
+  seq := s.String()
+  if seq == "" {
+    return text
+  }
+  return seq + text + Color(C_RESET)
+}
@@ -0,0 +1,75 @@
+package robologger
+
+import (
+  "testing"
+)
+
+func TestStyleString(t *testing.T) {
+  mode := GetColorMode()
+  defer SetColorMode(mode)
+  SetColorMode(CM_ALWAYS)
+
+  tests := []struct {
+    style Style
+    want string
+  }{
+    {Style{}, ""},
+    {Style{}.Foreground(ANSI16(1)), "\x1b[31m"},
+    {Style{}.Foreground(ANSI16(9)).Background(ANSI16(4)), "\x1b[91;44m"},
+    {Style{}.Foreground(ANSI256(3)), "\x1b[33m"},
+    {Style{}.Foreground(ANSI256(208)), "\x1b[38;5;208m"},
+    {Style{}.Background(ANSI256(240)), "\x1b[48;5;240m"},
+    {Style{}.Foreground(Hex("#ff8700")), "\x1b[38;5;208m"},
+    {Style{}.Foreground(Hex("#ff8800")), "\x1b[38;2;255;136;0m"},
+    {Style{}.Foreground(RGB(128, 128, 128)), "\x1b[38;5;244m"},
+    {Style{}.Bold().Underline().Foreground(ANSI16(2)), "\x1b[1;4;32m"},
+    {NewStyle(0, 0, A_DIM | A_BLINK | A_STRIKETHROUGH | A_ITALIC), "\x1b[2;3;5;9m"},
+  }
+
+  for _, test := range tests {
+    if got := test.style.String(); got != test.want {
+      t.Errorf("got %q, want %q", got, test.want)
+    }
+  }
+}
+
+func TestStyleTransition(t *testing.T) {
+  mode := GetColorMode()
+  defer SetColorMode(mode)
+  SetColorMode(CM_ALWAYS)
+
+  red := Style{}.Foreground(ANSI16(1))
+
+  tests := []struct {
+    from Style
+    to Style
+    want string
+  }{
+    {red, red, ""},
+    {Style{}, red, "\x1b[31m"},
+    {red, Style{}, "\x1b[m"},
+    {red, red.Bold(), "\x1b[1m"},
+    {red.Bold().Dim(), red.Dim(), "\x1b[22;2m"},
+    {red.Underline(), red.Italic(), "\x1b[24;3m"},
+    {red, red.Background(ANSI16(4)), "\x1b[44m"},
+    {red.Background(ANSI16(4)), red, "\x1b[49m"},
+    {red.Bold().Italic().Underline().Strikethrough(), Style{}.Foreground(ANSI16(2)), "\x1b[0;32m"},
+  }
+
+  for _, test := range tests {
+    if got := test.to.Transition(test.from); got != test.want {
+      t.Errorf("%v to %v: got %q, want %q", test.from, test.to, got, test.want)
+    }
+  }
+}
+
+func TestParseHex(t *testing.T) {
+  if c, err := ParseHex("#f80"); err != nil || c != RGB(0xff, 0x88, 0x00) {
+    t.Errorf("got %v, %v", c, err)
+  }
+  for _, s := range []string{"", "#ff88", "#gg8800", "#ff880000"} {
+    if _, err := ParseHex(s); err == nil {
+      t.Errorf("parsed invalid color %q", s)
+    }
+  }
+}
@@ -0,0 +1,302 @@
+package robologger
+
+import (
+  "bytes"
+  "errors"
+  "fmt"
+  "net"
+  "os"
+  "path/filepath"
+  "strings"
+  "sync"
+)
+
+// Syslog facilities, used to compute the priority of a syslog message. The kern
+// facility, 0, is only used by the kernel, so the zero Facility is LOG_USER.
+const (
+  _ = iota
+  LOG_USER
+  LOG_MAIL
+  LOG_DAEMON
+  LOG_AUTH
+  LOG_SYSLOG
+  LOG_LPR
+  LOG_NEWS
+  LOG_UUCP
+  LOG_CRON
+  LOG_AUTHPRIV
+  LOG_FTP
+
+  LOG_LOCAL0 = iota + 4
+  LOG_LOCAL1
+  LOG_LOCAL2
+  LOG_LOCAL3
+  LOG_LOCAL4
+  LOG_LOCAL5
+  LOG_LOCAL6
+  LOG_LOCAL7
+)
+
+// syslogSeverities maps the log levels to syslog severities.
+var syslogSeverities = map[LogFlag]int{
+  L_PRINT: 5, // notice
+  L_FATAL: 2, // crit
+  L_PANIC: 2, // crit
+  L_ERROR: 3, // err
+  L_WARN:  4, // warning
+  L_INFO:  6, // info
+  L_DEBUG: 7, // debug
+}
+
+// syslogEnterpriseID is the private enterprise number used in the structured
+// data IDs. 32473 is reserved for documentation by RFC 5612.
+const syslogEnterpriseID = "32473"
+
+// syslogPaths are the unix sockets of the local syslog daemon.
+var syslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogSinkOptions holds the options used when creating a new SyslogSink.
+type SyslogSinkOptions struct {
+  // Network and Address are the transport and address of the syslog daemon,
+  // e.g. "udp" and "localhost:514", or "unixgram" and "/dev/log". If Network
+  // is empty, the local syslog daemon is used.
+  Network string
+  Address string
+
+  // Facility is the syslog facility of the messages. It defaults to LOG_USER.
+  Facility int
+
+  // Hostname and AppName identify the sender of the messages. They default
+  // to the name of the host and of the program.
+  Hostname string
+  AppName string
+
+  // Level is the level threshold of the sink. It defaults to L_DEBUG.
+  Level LogFlag
+}
+
+// SyslogSink is a Sink which forwards messages to a syslog daemon using the RFC
+// 5424 format. The structured fields of a message are sent as structured data.
+// If writing to the daemon fails, the sink reconnects and tries once more.
+type SyslogSink struct {
+  mu sync.Mutex
+
+  opts SyslogSinkOptions
+
+  // conn is the connection to the syslog daemon, and network is its
+  // transport.
+  conn net.Conn
+  network string
+
+  closed bool
+}
+
+// NewSyslogSink returns a new SyslogSink configured with the options opts. It
+// returns an error if it cannot connect to the syslog daemon.
+func NewSyslogSink(opts SyslogSinkOptions) (*SyslogSink, error) {
+  if opts.Facility == 0 {
+    opts.Facility = LOG_USER
+  }
+  if opts.Hostname == "" {
+    opts.Hostname, _ = os.Hostname()
+  }
+  if opts.AppName == "" {
+    opts.AppName = filepath.Base(os.Args[0])
+  }
+  if opts.Level == 0 {
+    opts.Level = L_DEBUG
+  }
+
+  s := &SyslogSink{opts: opts}
+
+  if err := s.connect(); err != nil {
+    return nil, err
+  }
+
+  return s, nil
+}
+
+// connect connects to the syslog daemon.
+func (s *SyslogSink) connect() error {
+  if s.conn != nil {
+    s.conn.Close()
+    s.conn = nil
+  }
+
+  if s.opts.Network != "" {
+    conn, err := net.Dial(s.opts.Network, s.opts.Address)
+    if err != nil {
+      return err
+    }
+    s.conn, s.network = conn, s.opts.Network
+    return nil
+  }
+
+  // Try each of the local syslog sockets.
+  for _, network := range []string{"unixgram", "unix"} {
+    for _, path := range syslogPaths {
+      if conn, err := net.Dial(network, path); err == nil {
+        s.conn, s.network = conn, network
+        return nil
+      }
+    }
+  }
+
+  return errors.New("robologger: cannot connect to the local syslog daemon")
+}
+
+// Enabled is the implementation of the Sink interface.
+func (s *SyslogSink) Enabled(flags LogFlag) bool {
+  return atLevel(flags, s.opts.Level)
+}
+
+// Emit is the implementation of the Sink interface.
+func (s *SyslogSink) Emit(msg Message, update bool) error {
+  b := s.format(msg, update)
+
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  if s.closed {
+    return os.ErrClosed
+  }
+
+  if s.conn != nil {
+    if _, err := s.write(b); err == nil {
+      return nil
+    }
+  }
+
+  // The connection failed, so we reconnect and try once more.
+  if err := s.connect(); err != nil {
+    return err
+  }
+
+  _, err := s.write(b)
+  return err
+}
+
+// write writes a syslog message to the connection. Stream connections need a
+// trailing newline to separate the messages.
+func (s *SyslogSink) write(b []byte) (int, error) {
+  switch s.network {
+  case "udp", "udp4", "udp6", "unixgram":
+  default:
+    b = append(b, '\n')
+  }
+  return s.conn.Write(b)
+}
+
+// format returns the message formatted as an RFC 5424 syslog message.
+//
+//     <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+//
+// The kind of the message is used as the MSGID.
+func (s *SyslogSink) format(msg Message, update bool) []byte {
+  var buf bytes.Buffer
+
+  pri := s.opts.Facility * 8 + syslogSeverities[messageLevel(msg)]
+
+  // A message without a time has the nil value as its timestamp.
+  stamp := "-"
+  if t := messageTime(msg, update); !t.IsZero() {
+    stamp = t.Format("2006-01-02T15:04:05.000000Z07:00")
+  }
+
+  fmt.Fprintf(&buf, "<%d>1 %s %s %s %d %s ",
+    pri,
+    stamp,
+    syslogHeader(s.opts.Hostname, 255),
+    syslogHeader(s.opts.AppName, 48),
+    os.Getpid(),
+    messageKind(msg))
+
+  var sd bytes.Buffer
+
+  if fields := msg.Fields(); len(fields) > 0 {
+    sd.WriteString("[fields@" + syslogEnterpriseID)
+    for _, field := range fields {
+      writeSyslogParam(&sd, field.Key, fmt.Sprint(field.Value))
+    }
+    sd.WriteByte(']')
+  }
+
+  if lm, ok := msg.(*LogMessage); ok && lm.caller != nil {
+    sd.WriteString("[caller@" + syslogEnterpriseID)
+    writeSyslogParam(&sd, "file", lm.caller.File)
+    writeSyslogParam(&sd, "line", fmt.Sprint(lm.caller.Line))
+    writeSyslogParam(&sd, "func", lm.caller.Function)
+    sd.WriteByte(']')
+  }
+
+  if sd.Len() == 0 {
+    buf.WriteByte('-')
+  } else {
+    buf.Write(sd.Bytes())
+  }
+
+  buf.WriteByte(' ')
+  buf.WriteString(removeNewlines(msg.String()))
+
+  return buf.Bytes()
+}
+
+// syslogHeader returns the header field s with the characters which are not
+// allowed in a header replaced, and truncated to n characters. An empty field
+// is replaced by the nil value "-".
+func syslogHeader(s string, n int) string {
+  s = strings.Map(func(r rune) rune {
+    if r <= ' ' || r > '~' {
+      return '_'
+    }
+    return r
+  }, s)
+
+  if len(s) > n {
+    s = s[:n]
+  }
+  if s == "" {
+    return "-"
+  }
+  return s
+}
+
+// writeSyslogParam writes a structured data parameter to buf. The characters
+// which are not allowed in a parameter name are replaced, and the quote,
+// backslash and closing bracket are escaped in the value.
+func writeSyslogParam(buf *bytes.Buffer, name string, value string) {
+  name = strings.Map(func(r rune) rune {
+    if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+      return '_'
+    }
+    return r
+  }, name)
+
+  if len(name) > 32 {
+    name = name[:32]
+  }
+  if name == "" {
+    name = "_"
+  }
+
+  value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+
+  buf.WriteString(" " + name + `="` + removeNewlines(value) + `"`)
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *SyslogSink) Close() error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+
+  s.closed = true
+
+  if s.conn == nil {
+    return nil
+  }
+
+  err := s.conn.Close()
+  s.conn = nil
+
+  return err
+}
@@ -2,12 +2,16 @@ package robologger
 
 import (
   "bufio"
+  "errors"
   "fmt"
+  "io"
   "os"
-  "os/exec"
+  "os/signal"
   "regexp"
   "strconv"
   "strings"
+  "sync"
+  "syscall"
 )
 
 // The escape code for the ANSI terminal. Defaults to 27, which is "\033".
@@ -20,90 +24,220 @@ const (
 // specific commands.
 type Terminal struct {
   ESC int
+
+  // in is the input source, which is put in raw mode.
+  in io.Reader
+
+  // out is the output stream the control sequences are written to.
+  out io.Writer
 }
 
 func NewTerminal(esc int) *Terminal {
-  return &Terminal{esc}
+  return &Terminal{ESC: esc, in: Stdin, out: Stdout}
+}
+
+// SetOutput sets the output stream the control sequences are written to. It
+// should be the stream of the messages they affect.
+func (t *Terminal) SetOutput(out io.Writer) {
+  t.out = out
 }
 
-var term = NewTerminal(ESC)
+// IsTerminal reports whether w is a terminal. Only files, such as os.Stdout,
+// can be terminals.
+func IsTerminal(w io.Writer) bool {
+  f, ok := w.(*os.File)
+  return ok && isTerminal(f.Fd())
+}
 
 // The functions below provide simple terminal manipulation to move the cursor.
 func (t Terminal) Clear() {
-  fmt.Printf("%c[K", t.ESC)
+  fmt.Fprintf(t.out, "%c[K", t.ESC)
 }
 
 func (t Terminal) ClearScreen() {
-  fmt.Printf("%c[2J", t.ESC)
+  fmt.Fprintf(t.out, "%c[2J", t.ESC)
+}
+
+// ClearToEnd clears the screen from the cursor to the end of the screen.
+func (t Terminal) ClearToEnd() {
+  fmt.Fprintf(t.out, "%c[J", t.ESC)
 }
 
 // Should not be used, except in certain circumstances.
 func (t Terminal) Move(x int, y int) {
-	fmt.Printf("%c[%d;%dH", t.ESC, x, y)
+	fmt.Fprintf(t.out, "%c[%d;%dH", t.ESC, x, y)
 }
 
 func (t Terminal) MoveUp(n int) {
-  fmt.Printf("%c[%dA", t.ESC, n)
+  fmt.Fprintf(t.out, "%c[%dA", t.ESC, n)
 }
 
 func (t Terminal) MoveDown(n int) {
-  fmt.Printf("%c[%dB", t.ESC, n)
+  fmt.Fprintf(t.out, "%c[%dB", t.ESC, n)
 }
 
 func (t Terminal) MoveForward(n int) {
-  fmt.Printf("%c[%dC", t.ESC, n)
+  fmt.Fprintf(t.out, "%c[%dC", t.ESC, n)
 }
 
 func (t Terminal) MoveBackward(n int) {
-  fmt.Printf("%c[%dD", t.ESC, n)
+  fmt.Fprintf(t.out, "%c[%dD", t.ESC, n)
 }
 
 func (t Terminal) MoveToBeginning() {
-  fmt.Printf("\r")
+  fmt.Fprint(t.out, "\r")
 }
 
 func (t Terminal) HideCursor() {
-  fmt.Printf("%c[?25l", t.ESC)
+  fmt.Fprintf(t.out, "%c[?25l", t.ESC)
 }
 
 func (t Terminal) ShowCursor() {
-  fmt.Printf("%c[?25h", t.ESC)
+  fmt.Fprintf(t.out, "%c[?25h", t.ESC)
 }
 
 func (t Terminal) SaveCursorPosition() {
-  fmt.Printf("%c[s", t.ESC)
+  fmt.Fprintf(t.out, "%c[s", t.ESC)
 }
 
 func (t Terminal) RestoreCursorPosition() {
-  fmt.Printf("%c[u", t.ESC)
+  fmt.Fprintf(t.out, "%c[u", t.ESC)
+}
+
+// rawTerminals holds the state of the terminals in raw mode, saved before they
+// entered raw mode, so that they can be restored when the program is
+// interrupted or exits through Fatal.
+var rawTerminals = struct {
+  sync.Mutex
+  states map[uintptr]*termState
+
+  // signals receives SIGINT and SIGTERM while a terminal is in raw mode.
+  signals chan os.Signal
+}{
+  states: make(map[uintptr]*termState),
 }
 
-func (t Terminal) EnterRawMode() {
-  cmd := exec.Command("/bin/stty", "raw")
-  cmd.Stdin = os.Stdin
-  _ = cmd.Run()
-  cmd.Wait()
+// EnterRawMode puts the input terminal in raw mode, where input is available
+// byte by byte, without echo or line editing. It returns a function which
+// restores the previous state of the terminal. The terminal is also restored
+// if the program receives SIGINT or SIGTERM, or exits through Fatal.
+func (t Terminal) EnterRawMode() (restore func() error, err error) {
+  f, ok := t.in.(*os.File)
+  if !ok || !isTerminal(f.Fd()) {
+    return nil, errors.New("robologger: the input is not a terminal")
+  }
+
+  fd := f.Fd()
+
+  rawTerminals.Lock()
+  defer rawTerminals.Unlock()
+
+  // A terminal which is already in raw mode keeps its original state.
+  if _, ok := rawTerminals.states[fd]; ok {
+    return func() error { return restoreRawTerminal(fd) }, nil
+  }
+
+  st, err := makeRaw(fd)
+  if err != nil {
+    return nil, err
+  }
+
+  rawTerminals.states[fd] = st
+
+  if rawTerminals.signals == nil {
+    c := make(chan os.Signal, 1)
+    signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+    rawTerminals.signals = c
+
+    go func() {
+      sig, ok := <-c
+      if !ok {
+        return
+      }
+
+      restoreRawTerminals()
+
+      // Raise the signal again, now that it is not caught, so that the
+      // program exits as it would have.
+      if p, err := os.FindProcess(os.Getpid()); err != nil || p.Signal(sig) != nil {
+        os.Exit(1)
+      }
+    }()
+  }
+
+  return func() error { return restoreRawTerminal(fd) }, nil
 }
 
+// ExitRawMode restores the input terminal to the state it was in before it
+// entered raw mode.
 func (t Terminal) ExitRawMode() {
-  cmd := exec.Command("/bin/stty", "-raw")
-  cmd.Stdin = os.Stdin
-  _ = cmd.Run()
-  cmd.Wait()
+  if f, ok := t.in.(*os.File); ok {
+    restoreRawTerminal(f.Fd())
+  }
+}
+
+// restoreRawTerminal restores the terminal with the file descriptor fd, if it
+// is in raw mode.
+func restoreRawTerminal(fd uintptr) error {
+  rawTerminals.Lock()
+  defer rawTerminals.Unlock()
+
+  st, ok := rawTerminals.states[fd]
+  if !ok {
+    return nil
+  }
+
+  delete(rawTerminals.states, fd)
+
+  // Signals are only caught while a terminal is in raw mode.
+  if len(rawTerminals.states) == 0 && rawTerminals.signals != nil {
+    signal.Stop(rawTerminals.signals)
+    close(rawTerminals.signals)
+    rawTerminals.signals = nil
+  }
+
+  return restoreTerm(fd, st)
+}
+
+// restoreRawTerminals restores all of the terminals in raw mode.
+func restoreRawTerminals() {
+  rawTerminals.Lock()
+  defer rawTerminals.Unlock()
+
+  for fd, st := range rawTerminals.states {
+    restoreTerm(fd, st)
+    delete(rawTerminals.states, fd)
+  }
+
+  if rawTerminals.signals != nil {
+    signal.Stop(rawTerminals.signals)
+    close(rawTerminals.signals)
+    rawTerminals.signals = nil
+  }
+}
+
+// exit restores the terminals in raw mode and exits the program with the
+// status code.
+func exit(code int) {
+  restoreRawTerminals()
+  os.Exit(code)
 }
 
 func (t Terminal) GetCursorPosition() (int, int) {
-  t.EnterRawMode()
-  defer t.ExitRawMode()
+  restore, err := t.EnterRawMode()
+  if err != nil {
+    return 0, 0
+  }
+  defer restore()
 
   // cmd := exec.Command("echo", fmt.Sprintf("%c[6n", 27))
 	// randomBytes := &bytes.Buffer{}
 	// cmd.Stdout = randomBytes
   // _ = cmd.Start()
 
-  fmt.Printf(fmt.Sprintf("\r%c[6n", t.ESC))
+  fmt.Fprintf(t.out, "\r%c[6n", t.ESC)
 
-	reader := bufio.NewReader(os.Stdin)
+	reader := bufio.NewReader(t.in)
   // cmd.Wait()
 
   // fmt.Print(randomBytes)
@@ -0,0 +1,145 @@
+package robologger
+
+import (
+  "bytes"
+  "strings"
+  "testing"
+)
+
+func TestLoggerResize(t *testing.T) {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &buf,
+    PrintLength: 20,
+    Flags: PR_TTY | PR_NO_COLOR,
+  })
+
+  l.Info("Ready.")
+  l.Status("Calibrating the arm.")
+  l.Print("Done.")
+
+  msgs := l.History().Filter(func(Message) bool { return true })
+
+  for i, want := range []int{1, 1, 1} {
+    if n := msgs[i].getPrintLength(); n != want {
+      t.Fatalf("message %d: got %d lines before resize, want %d", i, n, want)
+    }
+  }
+
+  buf.Reset()
+  l.Resize(8, 0)
+
+  // "[INFO]  Ready." was printed on one line, which the terminal rewraps to two
+  // lines. The status and the message after it are redrawn at the new width.
+  for i, want := range []int{2, 3, 2} {
+    if n := msgs[i].getPrintLength(); n != want {
+      t.Errorf("message %d: got %d lines after resize, want %d", i, n, want)
+    }
+  }
+
+  // The cursor moves up over the five lines the status and the message after
+  // it now take, and the lines are cleared before they are redrawn.
+  want := "\x1b[?25l\r\x1b[5A\x1b[J" +
+    "\x1b[KCalibrat\n\x1b[King the \n\x1b[Karm.\n" +
+    "\x1b[K        \n\x1b[KDone.\n" +
+    "\x1b[?25h"
+
+  if got := buf.String(); got != want {
+    t.Errorf("got redraw %q, want %q", got, want)
+  }
+}
+
+func TestLoggerResizeUpdate(t *testing.T) {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &buf,
+    PrintLength: 40,
+    Flags: PR_TTY | PR_NO_COLOR,
+  })
+
+  status := l.Status("Homing.")
+  progress := l.Progress("Calibrating.")
+
+  // Run with -race: the messages are redrawn by Resize while they are updated.
+  done := make(chan struct{})
+  go func() {
+    defer close(done)
+    for i := 0; i < 100; i++ {
+      l.Resize(20 + i % 2 * 20, 0)
+    }
+  }()
+
+  for i := 0; i < 100; i++ {
+    status("Homing joint", i)
+    progress(i, "Calibrating joint", i)
+  }
+
+  <-done
+}
+
+func TestLoggerMultiline(t *testing.T) {
+  var buf bytes.Buffer
+
+  l := NewLogger(LoggerOptions{
+    Stdout: &buf,
+    Stderr: &buf,
+    PrintLength: 30,
+    Flags: PR_TTY | PR_NO_COLOR | PR_MULTILINE,
+  })
+
+  update := l.Status("Homing:\r\n  joint 1\n  joint 2")
+  l.Error("Motor stalled.\ngoroutine 1 [running]:\nmain.main()")
+
+  msgs := l.History().Filter(func(Message) bool { return true })
+
+  if n := msgs[1].getPrintLength(); n != 3 {
+    t.Errorf("got %d lines for the error, want 3", n)
+  }
+
+  want := "\x1b[KHoming:\n\x1b[K  joint 1\n\x1b[K  joint 2\n" +
+    "\x1b[K[ERROR] Motor stalled.\n\x1b[K        goroutine 1 [running]:\n\x1b[K        main.main()\n"
+
+  if got := buf.String(); got != want {
+    t.Errorf("got %q, want %q", got, want)
+  }
+
+  // The status is redrawn in place, six lines up from the bottom.
+  buf.Reset()
+  update("Homed.")
+
+  if got := buf.String(); !strings.HasPrefix(got, "\x1b[s\x1b[?25l\r\x1b[6A\x1b[KHomed.\n") {
+    t.Errorf("got update %q", got)
+  }
+  if n := msgs[0].getPrintLength(); n != 1 {
+    t.Errorf("got %d lines for the status after the update, want 1", n)
+  }
+
+  // The error is moved up, and the two lines left over below it are cleared.
+  if got := buf.String(); !strings.HasSuffix(got, "\x1b[K        main.main()\n\x1b[J\x1b[?25h") {
+    t.Errorf("got update %q", got)
+  }
+  // Tabs are expanded to the next tab stop, so the indented line of the stack
+  // trace takes two lines on the screen.
+  buf.Reset()
+  l.Error("goroutine 1:\n\t/usr/src/x.go:12")
+
+  trace := l.History().Get(-1)
+  if n := trace.getPrintLength(); n != 3 {
+    t.Errorf("got %d lines for the stack trace, want 3", n)
+  }
+
+  want = "\x1b[K[ERROR] goroutine 1:\n\x1b[K" + strings.Repeat(" ", 16) + "/usr/src/x.go:\n\x1b[K12\n"
+  if got := buf.String(); got != want {
+    t.Errorf("got %q, want %q", got, want)
+  }
+
+  // The status is now seven lines up from the bottom.
+  buf.Reset()
+  update("Homing again.")
+
+  if got := buf.String(); !strings.HasPrefix(got, "\x1b[s\x1b[?25l\r\x1b[7A\x1b[KHoming again.\n") {
+    t.Errorf("got update %q", got)
+  }
+}
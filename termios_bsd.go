@@ -0,0 +1,12 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package robologger
+
+import "syscall"
+
+// The ioctl requests used to read and write the terminal attributes.
+const (
+  ioctlReadTermios = syscall.TIOCGETA
+  ioctlWriteTermios = syscall.TIOCSETA
+)
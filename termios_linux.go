@@ -0,0 +1,9 @@
+package robologger
+
+import "syscall"
+
+// The ioctl requests used to read and write the terminal attributes.
+const (
+  ioctlReadTermios = syscall.TCGETS
+  ioctlWriteTermios = syscall.TCSETS
+)
@@ -0,0 +1,123 @@
+package robologger
+
+import (
+  "bytes"
+  "fmt"
+  "io"
+  "io/ioutil"
+  "os"
+  "strings"
+  "syscall"
+  "testing"
+  "unsafe"
+)
+
+// openPty opens a new pseudo-terminal and returns its master and slave ends.
+func openPty(t *testing.T) (*os.File, *os.File) {
+  master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+  if err != nil {
+    t.Skip("no pseudo-terminals:", err)
+  }
+
+  var n, unlock uint32
+  if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+    master.Close()
+    t.Skip("cannot unlock the pseudo-terminal:", errno)
+  }
+  if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+    master.Close()
+    t.Skip("cannot get the pseudo-terminal number:", errno)
+  }
+
+  slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR, 0)
+  if err != nil {
+    master.Close()
+    t.Skip("cannot open the pseudo-terminal:", err)
+  }
+
+  return master, slave
+}
+
+func TestEnterRawMode(t *testing.T) {
+  master, slave := openPty(t)
+  defer master.Close()
+  defer slave.Close()
+
+  lflag := func() uint32 {
+    var tios syscall.Termios
+    syscall.Syscall(syscall.SYS_IOCTL, slave.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&tios)))
+    return tios.Lflag
+  }
+
+  before := lflag()
+  if before&syscall.ECHO == 0 {
+    t.Fatal("the pseudo-terminal does not echo")
+  }
+
+  term := &Terminal{ESC: ESC, in: slave}
+
+  restore, err := term.EnterRawMode()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  if lflag()&(syscall.ECHO | syscall.ICANON) != 0 {
+    t.Error("the terminal is not in raw mode")
+  }
+  if rawTerminals.signals == nil {
+    t.Error("signals are not caught in raw mode")
+  }
+
+  if err := restore(); err != nil {
+    t.Fatal(err)
+  }
+
+  if after := lflag(); after != before {
+    t.Errorf("got local flags %#x after restore, want %#x", after, before)
+  }
+  if rawTerminals.signals != nil {
+    t.Error("signals are still caught after restore")
+  }
+
+  if _, err := (&Terminal{ESC: ESC, in: strings.NewReader("")}).EnterRawMode(); err == nil {
+    t.Error("raw mode entered on an input which is not a terminal")
+  }
+}
+
+func TestSameScreen(t *testing.T) {
+  master, slave := openPty(t)
+  defer master.Close()
+  defer slave.Close()
+
+  // stderr is a separate file on the same terminal as stdout.
+  stderr, err := os.OpenFile(slave.Name(), os.O_RDWR, 0)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer stderr.Close()
+
+  go io.Copy(ioutil.Discard, master)
+
+  l := NewLogger(LoggerOptions{Stdout: slave, Stderr: stderr, PrintLength: 80})
+  l.Status("Homing.")
+  l.Error("Axis 2 fault.")
+
+  h := l.History()
+  if h.screenOf(h.Get(0)) != h.screenOf(h.Get(1)) {
+    t.Error("stdout and stderr on the same terminal are not the same screen")
+  }
+  if offset := h.getPrintOffset(h.Get(0), h.screenID(slave)); offset != 2 {
+    t.Errorf("got print offset %d, want 2", offset)
+  }
+
+  var buf bytes.Buffer
+
+  l = NewLogger(LoggerOptions{Stdout: slave, Stderr: &buf, PrintLength: 80})
+  l.Status("Homing.")
+  l.Error("Axis 2 fault.")
+
+  h = l.History()
+  if offset := h.getPrintOffset(h.Get(0), h.screenID(slave)); offset != 1 {
+    t.Errorf("got print offset %d with a separate stderr, want 1", offset)
+  }
+}
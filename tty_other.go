@@ -0,0 +1,41 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package robologger
+
+import (
+  "errors"
+  "os"
+)
+
+// isTerminal reports whether the file descriptor fd is a terminal. Terminals
+// are only detected on unix systems.
+func isTerminal(fd uintptr) bool {
+  return false
+}
+
+// terminalSize returns the number of columns and rows of the terminal with
+// the file descriptor fd. Terminals are only detected on unix systems.
+func terminalSize(fd uintptr) (cols int, rows int, ok bool) {
+  return 0, 0, false
+}
+
+// notifyResize relays the signals sent when the terminal is resized to c.
+// There is no such signal outside of unix systems.
+func notifyResize(c chan<- os.Signal) {
+}
+
+// termState is the state of a terminal, saved before entering raw mode.
+type termState struct{}
+
+// makeRaw puts the terminal with the file descriptor fd in raw mode. Raw mode
+// is only supported on unix systems.
+func makeRaw(fd uintptr) (*termState, error) {
+  return nil, errors.New("robologger: raw mode is not supported on this platform")
+}
+
+// restoreTerm restores the terminal with the file descriptor fd to the state
+// st.
+func restoreTerm(fd uintptr, st *termState) error {
+  return nil
+}
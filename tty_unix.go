@@ -0,0 +1,84 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package robologger
+
+import (
+  "os"
+  "os/signal"
+  "syscall"
+  "unsafe"
+)
+
+// isTerminal reports whether the file descriptor fd is a terminal. Only a
+// terminal has terminal attributes to read.
+func isTerminal(fd uintptr) bool {
+  var t syscall.Termios
+  _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlReadTermios, uintptr(unsafe.Pointer(&t)))
+  return errno == 0
+}
+
+// winsize is the window size structure of the TIOCGWINSZ ioctl.
+type winsize struct {
+  rows uint16
+  cols uint16
+  xpixel uint16
+  ypixel uint16
+}
+
+// terminalSize returns the number of columns and rows of the terminal with
+// the file descriptor fd.
+func terminalSize(fd uintptr) (cols int, rows int, ok bool) {
+  var ws winsize
+  _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+  if errno != 0 || ws.cols == 0 {
+    return 0, 0, false
+  }
+  return int(ws.cols), int(ws.rows), true
+}
+
+// notifyResize relays the signals sent when the terminal is resized to c.
+func notifyResize(c chan<- os.Signal) {
+  signal.Notify(c, syscall.SIGWINCH)
+}
+
+// termState is the state of a terminal, saved before entering raw mode.
+type termState struct {
+  termios syscall.Termios
+}
+
+// makeRaw puts the terminal with the file descriptor fd in raw mode, like
+// cfmakeraw(3), and returns its previous state.
+func makeRaw(fd uintptr) (*termState, error) {
+  var old termState
+  _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlReadTermios, uintptr(unsafe.Pointer(&old.termios)))
+  if errno != 0 {
+    return nil, errno
+  }
+
+  raw := old.termios
+  raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+  raw.Oflag &^= syscall.OPOST
+  raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+  raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+  raw.Cflag |= syscall.CS8
+  raw.Cc[syscall.VMIN] = 1
+  raw.Cc[syscall.VTIME] = 0
+
+  _, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlWriteTermios, uintptr(unsafe.Pointer(&raw)))
+  if errno != 0 {
+    return nil, errno
+  }
+
+  return &old, nil
+}
+
+// restoreTerm restores the terminal with the file descriptor fd to the state
+// st.
+func restoreTerm(fd uintptr, st *termState) error {
+  _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, ioctlWriteTermios, uintptr(unsafe.Pointer(&st.termios)))
+  if errno != 0 {
+    return errno
+  }
+  return nil
+}
@@ -0,0 +1,390 @@
+package robologger
+
+import (
+  "sort"
+  "strings"
+  "unicode"
+  "unicode/utf8"
+)
+
+// runeRange is a range of runes, from lo to hi inclusive.
+type runeRange struct {
+  lo rune
+  hi rune
+}
+
+// wideRunes are the runes which take two columns on the screen: the East Asian
+// Wide and Fullwidth characters, which include the emoji with the default emoji
+// presentation.
+var wideRunes = []runeRange{
+  {0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+  {0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+  {0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+  {0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+  {0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+  {0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+  {0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+  {0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+  {0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+  {0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+  {0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+  {0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+  {0x17000, 0x18CFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+  {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F202}, {0x1F210, 0x1F23B},
+  {0x1F240, 0x1F248}, {0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F320},
+  {0x1F32D, 0x1F335}, {0x1F337, 0x1F37C}, {0x1F37E, 0x1F393}, {0x1F3A0, 0x1F3CA},
+  {0x1F3CF, 0x1F3D3}, {0x1F3E0, 0x1F3F0}, {0x1F3F4, 0x1F3F4}, {0x1F3F8, 0x1F43E},
+  {0x1F440, 0x1F440}, {0x1F442, 0x1F4FC}, {0x1F4FF, 0x1F53D}, {0x1F54B, 0x1F54E},
+  {0x1F550, 0x1F567}, {0x1F57A, 0x1F57A}, {0x1F595, 0x1F596}, {0x1F5A4, 0x1F5A4},
+  {0x1F5FB, 0x1F64F}, {0x1F680, 0x1F6C5}, {0x1F6CC, 0x1F6CC}, {0x1F6D0, 0x1F6D2},
+  {0x1F6D5, 0x1F6D7}, {0x1F6DC, 0x1F6DF}, {0x1F6EB, 0x1F6EC}, {0x1F6F4, 0x1F6FC},
+  {0x1F7E0, 0x1F7EB}, {0x1F7F0, 0x1F7F0}, {0x1F90C, 0x1F93A}, {0x1F93C, 0x1F945},
+  {0x1F947, 0x1F9FF}, {0x1FA70, 0x1FAFF}, {0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// inRanges reports whether the rune r is in one of the sorted ranges.
+func inRanges(r rune, ranges []runeRange) bool {
+  i := sort.Search(len(ranges), func(i int) bool {
+    return ranges[i].hi >= r
+  })
+  return i < len(ranges) && ranges[i].lo <= r
+}
+
+// runeWidth returns the number of columns the rune r takes on the screen when
+// it starts a grapheme cluster.
+func runeWidth(r rune) int {
+  switch {
+  case r < 0x20 || (r >= 0x7F && r < 0xA0):
+    return 0
+  case r < 0x300:
+    return 1
+  case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+    return 0
+  case r >= 0x1160 && r <= 0x11FF:
+    // Hangul medial vowels and final consonants join the syllable before.
+    return 0
+  case inRanges(r, wideRunes):
+    return 2
+  }
+  return 1
+}
+
+// isRegionalIndicator reports whether the rune r is a regional indicator, a
+// pair of which forms a flag.
+func isRegionalIndicator(r rune) bool {
+  return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isGraphemeExtend reports whether the rune r extends the grapheme cluster it
+// follows, without changing its width: combining marks, emoji modifiers, tags
+// and Hangul medial vowels and final consonants.
+func isGraphemeExtend(r rune) bool {
+  switch {
+  case r >= 0x1F3FB && r <= 0x1F3FF:
+    return true
+  case r >= 0xE0020 && r <= 0xE007F:
+    return true
+  case r >= 0x1160 && r <= 0x11FF:
+    return true
+  }
+  return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+// nextGrapheme returns the length in bytes of the grapheme cluster at the start
+// of s, and the number of columns it takes on the screen. The width of a
+// cluster is the width of its first rune, except that a variation selector
+// switches it to the emoji (two columns) or text (one column) presentation,
+// and that a pair of regional indicators forms a two column flag.
+func nextGrapheme(s string) (n int, width int) {
+  r, n := utf8.DecodeRuneInString(s)
+  if r == '\r' && strings.HasPrefix(s[n:], "\n") {
+    return n + 1, 0
+  }
+
+  width = runeWidth(r)
+
+  // ri is true while a regional indicator waits for its pair, and zwj is true
+  // after a zero width joiner, which joins the next rune to the cluster.
+  ri := isRegionalIndicator(r)
+  zwj := false
+
+  for n < len(s) {
+    r, size := utf8.DecodeRuneInString(s[n:])
+
+    switch {
+    case zwj:
+      zwj = false
+    case ri && isRegionalIndicator(r):
+      ri = false
+      width = 2
+    case r == 0x200D:
+      zwj = true
+    case r == 0xFE0F:
+      if width == 1 {
+        width = 2
+      }
+    case r == 0xFE0E:
+      if width == 2 {
+        width = 1
+      }
+    case isGraphemeExtend(r):
+    default:
+      return n, width
+    }
+
+    n = n + size
+  }
+
+  return n, width
+}
+
+// ansiLength returns the length of the ANSI escape sequence at the start of s,
+// or zero if s does not start with one.
+func ansiLength(s string) int {
+  if !strings.HasPrefix(s, "\x1b[") {
+    return 0
+  }
+
+  for i := 2; i < len(s); i++ {
+    c := s[i]
+    switch {
+    case c >= '0' && c <= '9', c == ';', c == '?':
+    case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+      return i + 1
+    default:
+      return 0
+    }
+  }
+
+  return 0
+}
+
+// StringWidth returns the number of columns the string s takes on the screen.
+// Wide characters, such as CJK characters and most emoji, take two columns,
+// and combining marks and ANSI escape sequences take none.
+func StringWidth(s string) (width int) {
+  for len(s) > 0 {
+    if n := ansiLength(s); n > 0 {
+      s = s[n:]
+      continue
+    }
+
+    n, w := nextGrapheme(s)
+    width = width + w
+    s = s[n:]
+  }
+
+  return
+}
+
+// tabWidth is the distance between the tab stops of the terminal.
+const tabWidth = 8
+
+// expandTabs replaces the tabs in the line s by spaces up to the next tab stop,
+// so that the width of the line does not depend on where the terminal wraps it.
+func expandTabs(s string) string {
+  if !strings.Contains(s, "\t") {
+    return s
+  }
+
+  var b strings.Builder
+  var col int
+
+  for len(s) > 0 {
+    if n := ansiLength(s); n > 0 {
+      b.WriteString(s[:n])
+      s = s[n:]
+      continue
+    }
+
+    n, cw := nextGrapheme(s)
+
+    if s[0] == '\t' {
+      cw = tabWidth - col % tabWidth
+      b.WriteString(strings.Repeat(" ", cw))
+    } else {
+      b.WriteString(s[:n])
+    }
+
+    col = col + cw
+    s = s[n:]
+  }
+
+  return b.String()
+}
+
+// wrap splits s into lines which take at most w columns on the screen, and
+// returns the lines and the number of columns each of them takes. Grapheme
+// clusters are never split, and ANSI escape sequences take no space. If w is
+// not positive, s is not wrapped.
+func wrap(s string, w int) (lines []string, widths []int) {
+  var b strings.Builder
+
+  // col holds the number of columns taken by the current line.
+  var col int
+
+  for len(s) > 0 {
+    if n := ansiLength(s); n > 0 {
+      b.WriteString(s[:n])
+      s = s[n:]
+      continue
+    }
+
+    n, cw := nextGrapheme(s)
+
+    if w > 0 && col > 0 && col + cw > w {
+      lines = append(lines, b.String())
+      widths = append(widths, col)
+      b.Reset()
+      col = 0
+    }
+
+    b.WriteString(s[:n])
+    col = col + cw
+    s = s[n:]
+  }
+
+  return append(lines, b.String()), append(widths, col)
+}
+
+// truncate shortens s to at most w columns on the screen, ending it with "..."
+// if it is cut. ANSI escape sequences are kept.
+func truncate(s string, w int) string {
+  if StringWidth(s) <= w {
+    return s
+  }
+
+  var b strings.Builder
+  var col int
+
+  for len(s) > 0 {
+    if n := ansiLength(s); n > 0 {
+      b.WriteString(s[:n])
+      s = s[n:]
+      continue
+    }
+
+    n, cw := nextGrapheme(s)
+    if col + cw > w - 3 {
+      break
+    }
+
+    b.WriteString(s[:n])
+    col = col + cw
+    s = s[n:]
+  }
+
+  return b.String() + "..."
+}
+
+// wrapWords splits s into lines which take at most w columns on the screen,
+// breaking them at spaces, and returns the lines and the number of columns each
+// of them takes. Continuation lines are indented by indent columns, and the
+// colors active at the end of a line are turned off before the line break and
+// turned on again after the indent. Words which do not fit on a line by
+// themselves, such as long paths and URLs, are broken where the line is full.
+// If w is not positive, s is not wrapped.
+func wrapWords(s string, w int, indent int) (lines []string, widths []int) {
+  if w <= 0 {
+    return wrap(s, w)
+  }
+
+  var line strings.Builder
+
+  // col holds the number of columns taken by the current line, and empty is
+  // true until a word is written to it.
+  var col int
+  var empty = true
+
+  // active holds the SGR sequences in effect, since the last reset.
+  var active string
+
+  // space holds the spaces before the next word, which are dropped at a line
+  // break.
+  var space string
+
+  newline := func() {
+    if active != "" {
+      line.WriteString("\x1b[0m")
+    }
+
+    lines = append(lines, line.String())
+    widths = append(widths, col)
+
+    line.Reset()
+    line.WriteString(strings.Repeat(" ", indent))
+    line.WriteString(active)
+
+    col, empty, space = indent, true, ""
+  }
+
+  // put writes the word to the line. If split is true, the word is broken
+  // between grapheme clusters where the line is full.
+  put := func(word string, split bool) {
+    for len(word) > 0 {
+      if n := ansiLength(word); n > 0 {
+        seq := word[:n]
+
+        switch {
+        case seq == "\x1b[0m" || seq == "\x1b[m":
+          active = ""
+        case seq[n - 1] == 'm':
+          active = active + seq
+        }
+
+        line.WriteString(seq)
+        word = word[n:]
+        continue
+      }
+
+      n, cw := nextGrapheme(word)
+
+      if split && !empty && col + cw > w {
+        newline()
+      }
+
+      line.WriteString(word[:n])
+      col, empty = col + cw, false
+      word = word[n:]
+    }
+  }
+
+  for len(s) > 0 {
+    if s[0] == ' ' {
+      space = space + " "
+      s = s[1:]
+      continue
+    }
+
+    end := strings.IndexByte(s, ' ')
+    if end < 0 {
+      end = len(s)
+    }
+
+    word := s[:end]
+    ww := StringWidth(word)
+    s = s[end:]
+
+    // A word which does not fit on the line starts a new line, unless it is
+    // too long to fit on a line by itself.
+    if !empty && col + len(space) + ww > w && indent + ww <= w {
+      newline()
+    }
+
+    if col + len(space) <= w {
+      line.WriteString(space)
+      col = col + len(space)
+    }
+    space = ""
+
+    put(word, col + ww > w)
+  }
+
+  // Trailing spaces are kept, e.g. for the input typed after a prompt.
+  if col + len(space) <= w {
+    line.WriteString(space)
+    col = col + len(space)
+  }
+
+  return append(lines, line.String()), append(widths, col)
+}
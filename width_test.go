@@ -0,0 +1,106 @@
+package robologger
+
+import (
+  "reflect"
+  "testing"
+)
+
+func TestStringWidth(t *testing.T) {
+  tests := []struct {
+    s string
+    width int
+  }{
+    {"robot", 5},
+    {"🤖", 2},
+    {"ロボット", 8},
+    {"로봇", 4},
+    {"é", 1},
+    {"❤", 1},
+    {"❤️", 2},
+    {"⌚︎", 1},
+    {"👩‍🔬", 2},
+    {"👍🏽", 2},
+    {"🇯🇵", 2},
+    {"\x1b[31m[ERROR]\x1b[0m", 7},
+  }
+
+  for _, test := range tests {
+    if width := StringWidth(test.s); width != test.width {
+      t.Errorf("StringWidth(%+q) = %d, want %d", test.s, width, test.width)
+    }
+  }
+}
+
+func TestWrap(t *testing.T) {
+  tests := []struct {
+    s string
+    w int
+    lines []string
+    widths []int
+  }{
+    {"abcdef", 3, []string{"abc", "def"}, []int{3, 3}},
+    {"ab🤖cd", 3, []string{"ab", "🤖c", "d"}, []int{2, 3, 1}},
+    {"ロボット", 5, []string{"ロボ", "ット"}, []int{4, 4}},
+    {"aéé", 2, []string{"aé", "é"}, []int{2, 1}},
+    {"\x1b[32mabcd\x1b[0m", 2, []string{"\x1b[32mab", "cd\x1b[0m"}, []int{2, 2}},
+    {"abc", 0, []string{"abc"}, []int{3}},
+  }
+
+  for _, test := range tests {
+    lines, widths := wrap(test.s, test.w)
+    if !reflect.DeepEqual(lines, test.lines) || !reflect.DeepEqual(widths, test.widths) {
+      t.Errorf("wrap(%+q, %d) = %+q, %v, want %+q, %v", test.s, test.w, lines, widths, test.lines, test.widths)
+    }
+  }
+}
+
+func TestTruncate(t *testing.T) {
+  if s := truncate("Calibrating 🤖 arm", 12); s != "Calibrati..." {
+    t.Errorf("got %q", s)
+  }
+  if s := truncate("ロボットの腕", 9); s != "ロボッ..." {
+    t.Errorf("got %q", s)
+  }
+  if s := truncate("arm", 9); s != "arm" {
+    t.Errorf("got %q", s)
+  }
+}
+
+func TestWrapWords(t *testing.T) {
+  tests := []struct {
+    s string
+    w int
+    indent int
+    lines []string
+  }{
+    {
+      "[INFO]  The arm is calibrated.", 20, 8,
+      []string{"[INFO]  The arm is", "        calibrated."},
+    },
+    {
+      "[INFO]  Loading /usr/share/robot/arm.cfg now", 20, 8,
+      []string{"[INFO]  Loading /usr", "        /share/robot", "        /arm.cfg now"},
+    },
+    {
+      "Motor \x1b[31mstalled at joint\x1b[0m two.", 15, 2,
+      []string{"Motor \x1b[31mstalled\x1b[0m", "  \x1b[31mat joint\x1b[0m two."},
+    },
+    {
+      "Continue? [y/n] ", 20, 0,
+      []string{"Continue? [y/n] "},
+    },
+  }
+
+  for _, test := range tests {
+    lines, widths := wrapWords(test.s, test.w, test.indent)
+    if !reflect.DeepEqual(lines, test.lines) {
+      t.Errorf("wrapWords(%+q, %d, %d) = %+q, want %+q", test.s, test.w, test.indent, lines, test.lines)
+    }
+
+    for i, line := range lines {
+      if widths[i] != StringWidth(line) || widths[i] > test.w {
+        t.Errorf("line %+q has width %d, got %d", line, StringWidth(line), widths[i])
+      }
+    }
+  }
+}
@@ -0,0 +1,166 @@
+package robologger
+
+import (
+  "bytes"
+  "log"
+  "strings"
+  "sync"
+)
+
+// levelPrefixes are the prefixes used to detect the level of a line written to
+// a LogWriter, in the order they are checked.
+var levelPrefixes = []struct {
+  prefix string
+  flags LogFlag
+}{
+  {"fatal", L_FATAL},
+  {"panic", L_PANIC},
+  {"error", L_ERROR},
+  {"err", L_ERROR},
+  {"warning", L_WARN},
+  {"warn", L_WARN},
+  {"info", L_INFO},
+  {"debug", L_DEBUG},
+}
+
+// LogWriter is an io.Writer which sends each line written to it through a
+// Logger, so that output from code which only knows about io.Writer, such as
+// the standard log package, does not corrupt the live Status and Progress
+// lines of the logger.
+//
+// Each line is printed at the default level of the writer. If level detection
+// is on, lines starting with a level, e.g. "ERROR", "[warn]" or "warn:", are
+// printed at that level instead, and the level is removed from the line.
+type LogWriter struct {
+  mu sync.Mutex
+
+  l *Logger
+  level LogFlag
+  detect bool
+
+  // buf holds the last line written, until it is completed by a newline.
+  buf []byte
+}
+
+// NewLogWriter returns a new LogWriter which prints lines using the logger l at
+// the level level. If detect is true, the level of each line is detected from
+// its prefix. If l is nil, the default logger is used.
+func NewLogWriter(l *Logger, level LogFlag, detect bool) *LogWriter {
+  if l == nil {
+    l = std
+  }
+
+  return &LogWriter{
+    l: l,
+    level: level,
+    detect: detect,
+  }
+}
+
+// Write is the implementation of the io.Writer interface. Incomplete lines are
+// kept until the rest of the line is written, or until Flush is called.
+func (w *LogWriter) Write(p []byte) (n int, err error) {
+  w.mu.Lock()
+  defer w.mu.Unlock()
+
+  w.buf = append(w.buf, p...)
+
+  for {
+    i := bytes.IndexByte(w.buf, '\n')
+    if i < 0 {
+      break
+    }
+
+    w.print(string(bytes.TrimSuffix(w.buf[:i], []byte("\r"))))
+    w.buf = w.buf[i + 1:]
+  }
+
+  return len(p), nil
+}
+
+// Flush prints the incomplete line held by the writer, if any.
+func (w *LogWriter) Flush() error {
+  w.mu.Lock()
+  defer w.mu.Unlock()
+
+  if len(w.buf) > 0 {
+    w.print(string(w.buf))
+    w.buf = nil
+  }
+
+  return nil
+}
+
+// print prints a single line using the logger.
+func (w *LogWriter) print(line string) {
+  flags := w.level
+
+  if w.detect {
+    flags, line = detectLevel(line, flags)
+  }
+
+  if !w.l.Enabled(flags) {
+    return
+  }
+
+  w.l.output(NewLogMessage(flags, nil, line), true)
+}
+
+// detectLevel returns the level the line starts with and the line without the
+// level. If the line does not start with a level, flags and the line are
+// returned unchanged.
+func detectLevel(line string, flags LogFlag) (LogFlag, string) {
+  s := strings.TrimLeft(line, " \t")
+
+  bracket := strings.HasPrefix(s, "[")
+  if bracket {
+    s = s[1:]
+  }
+
+  for _, lp := range levelPrefixes {
+    if len(s) < len(lp.prefix) || !strings.EqualFold(s[:len(lp.prefix)], lp.prefix) {
+      continue
+    }
+
+    rest := s[len(lp.prefix):]
+
+    // The level must be followed by a separator, so that e.g. "information"
+    // is not mistaken for a level.
+    switch {
+    case bracket && strings.HasPrefix(rest, "]"):
+      rest = rest[1:]
+    case bracket:
+      continue
+    case rest == "":
+    case rest[0] == ':' || rest[0] == ' ' || rest[0] == '\t':
+    default:
+      continue
+    }
+
+    rest = strings.TrimPrefix(rest, ":")
+    return lp.flags, strings.TrimLeft(rest, " \t")
+  }
+
+  return flags, line
+}
+
+// RedirectStdLog redirects the output of the standard log package to a
+// LogWriter which prints lines using the logger l at the L_INFO level, with
+// level detection. The flags of the standard logger are cleared, since the
+// logger adds its own timestamps. If l is nil, the default logger is used.
+//
+// The returned function restores the previous output and flags of the
+// standard log package.
+func RedirectStdLog(l *Logger) func() {
+  flags, prefix, out := log.Flags(), log.Prefix(), log.Writer()
+
+  log.SetFlags(0)
+  log.SetPrefix("")
+  log.SetOutput(NewLogWriter(l, L_INFO, true))
+
+  return func() {
+    log.SetFlags(flags)
+    log.SetPrefix(prefix)
+    log.SetOutput(out)
+  }
+}